@@ -0,0 +1,326 @@
+package artworks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/jcleira/handler/handler"
+)
+
+// APIKeyHeader is the request header holding a static API key.
+const APIKeyHeader = "X-API-Key"
+
+// Authentication methods of a Principal.
+const (
+	AuthAPIKey = "api-key"
+	AuthJWT    = "jwt"
+)
+
+// APIKey is a static API key, only the SHA-256 hash of the key is stored so
+// the key itself is only known when it's created.
+type APIKey struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Role      Role   `json:"role"`
+	CreatedAt int    `json:"created_at"`
+	Key       string `json:"key,omitempty"`
+}
+
+// APIKeysController is implemented by the Client, it manages the API keys
+// used by the Authenticator.
+type APIKeysController interface {
+	FindAPIKey(context.Context, string) (*APIKey, error)
+	CreateAPIKey(context.Context, string, Role) (*APIKey, error)
+	RevokeAPIKey(context.Context, int) error
+}
+
+// Principal is who performs an authenticated request, it's available on the
+// request context through PrincipalFromContext.
+type Principal struct {
+	// Subject is the API key name or the JWT 'sub' claim.
+	Subject string
+	// Method is either AuthAPIKey or AuthJWT.
+	Method string
+	// Role is the API key role or the JWT 'role' claim, RoleViewer if the
+	// token has none.
+	Role Role
+}
+
+// principalKey is the request context key of the Principal.
+type principalKey struct{}
+
+// PrincipalFromContext returns the Principal of an authenticated request.
+//
+// ctx: The request context.
+//
+// Returns the Principal, nil if the request wasn't authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// Authenticator authenticates the API requests either by a static API key on
+// the X-API-Key header or by a JWT bearer token on the Authorization header.
+//
+// JWT tokens are only accepted when HS256Secret or RS256Key are set, signed
+// with the matching algorithm. The 'iss' and 'aud' claims are checked when
+// Issuer and Audience are set, the 'exp' and 'nbf' claims always are, tokens
+// without an 'exp' claim are rejected.
+type Authenticator struct {
+	APIKeys     APIKeysController
+	HS256Secret []byte
+	RS256Key    *rsa.PublicKey
+	Issuer      string
+	Audience    string
+}
+
+// Middleware wraps a http.Handler so it's only reached by authenticated
+// requests, the Principal is added to the request context. Requests without
+// credentials or with invalid ones get a 401 Unauthorized problem response.
+//
+// CORS preflight requests are answered by CORS.Handler before reaching the
+// router, any other OPTIONS request is authenticated as the rest.
+//
+// next: The http.Handler to protect.
+//
+// Returns a http.Handler ready to be added to a HTTP server / router.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.Authenticate(r)
+		if err != nil {
+			if errors.Is(err, ErrUnauthenticated) {
+				challenge := `Bearer realm="artworks"`
+				if r.Header.Get("Authorization") != "" || r.Header.Get(APIKeyHeader) != "" {
+					challenge += `, error="invalid_token"`
+				}
+				w.Header().Set("WWW-Authenticate", challenge)
+			}
+
+			ProblemHandler(func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+				return httpError(err, http.StatusInternalServerError)
+			}).ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// Authenticate checks the request credentials.
+//
+// r: The request.
+//
+// Returns:
+// The Principal performing the request.
+// An Error of the ErrUnauthenticated kind if the credentials are missing or not
+// valid, any other error if they couldn't be checked.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		apiKey, err := a.APIKeys.FindAPIKey(r.Context(), key)
+		if err != nil {
+			return nil, err
+		}
+
+		if apiKey == nil {
+			return nil, newError(ErrUnauthenticated, "The API key is not valid")
+		}
+
+		return &Principal{Subject: apiKey.Name, Method: AuthAPIKey, Role: apiKey.Role}, nil
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, newError(ErrUnauthenticated, "The request has no credentials, an API key or a bearer token is required")
+	}
+
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return nil, newError(ErrUnauthenticated, "The Authorization header should hold a bearer token")
+	}
+
+	principal, err := a.verifyToken(strings.TrimSpace(authorization[7:]))
+	if err != nil {
+		return nil, newError(ErrUnauthenticated, "The bearer token is not valid, %s", err)
+	}
+
+	return principal, nil
+}
+
+// verifyToken checks a JWT token signature and claims.
+//
+// token: The encoded token.
+//
+// Returns:
+// The Principal, by the token 'sub' and 'role' claims.
+// An error if the token is not valid.
+func (a *Authenticator) verifyToken(token string) (*Principal, error) {
+	var methods []string
+	if len(a.HS256Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.RS256Key != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("bearer tokens are not enabled")
+	}
+
+	parser := &jwt.Parser{ValidMethods: methods}
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() == jwt.SigningMethodRS256.Alg() {
+			return a.RS256Key, nil
+		}
+
+		return a.HS256Secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The parser only checks the 'exp' claim when present, tokens that never
+	// expire are not accepted.
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("the expiration is missing")
+	}
+
+	if a.Issuer != "" && !claims.VerifyIssuer(a.Issuer, true) {
+		return nil, errors.New("unexpected issuer")
+	}
+
+	if a.Audience != "" && !claims.VerifyAudience(a.Audience, true) {
+		return nil, errors.New("unexpected audience")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("the subject is missing")
+	}
+
+	name, _ := claims["role"].(string)
+	role, err := ParseRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Principal{Subject: subject, Method: AuthJWT, Role: role}, nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 hash of an API key, as stored
+// on the api_keys table.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindAPIKey fetches a non revoked API key by the key itself.
+//
+// key: The API key as sent by the client.
+//
+// Returns:
+// The APIKey, nil if there isn't a valid one.
+// An error if any.
+func (c *Client) FindAPIKey(ctx context.Context, key string) (*APIKey, error) {
+	ctx, cancel := c.withTimeout(ctx, "FindAPIKey")
+	defer cancel()
+
+	var apiKey APIKey
+	err := c.DB.QueryRowContext(ctx, "SELECT id, name, role, created_at FROM api_keys WHERE key_hash=? AND revoked_at IS NULL",
+		hashAPIKey(key)).Scan(&apiKey.ID, &apiKey.Name, &apiKey.Role, &apiKey.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the api_keys table. Err: %w", err)
+	}
+
+	return &apiKey, nil
+}
+
+// CreateAPIKey generates a new random API key, only its hash is stored.
+//
+// name: Who the API key is for, it's used as the Principal subject.
+// role: The Principal Role, RoleViewer if empty.
+//
+// Returns:
+// The APIKey, Key holds the API key itself, it can't be recovered later.
+// An error if any.
+func (c *Client) CreateAPIKey(ctx context.Context, name string, role Role) (*APIKey, error) {
+	ctx, cancel := c.withTimeout(ctx, "CreateAPIKey")
+	defer cancel()
+
+	if strings.TrimSpace(name) == "" {
+		return nil, &Error{Kind: ErrValidation, Detail: "The API key name is required",
+			Fields: []FieldError{{Field: "name", Message: "is required"}}}
+	}
+
+	role, err := ParseRole(string(role))
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("Unable to generate the API key. Err: %w", err)
+	}
+
+	apiKey := &APIKey{
+		Name:      strings.TrimSpace(name),
+		Role:      role,
+		CreatedAt: int(time.Now().Unix()),
+		Key:       hex.EncodeToString(secret),
+	}
+
+	result, err := c.DB.ExecContext(ctx, "INSERT INTO api_keys (name, role, key_hash, created_at) VALUES (?, ?, ?, ?)",
+		apiKey.Name, apiKey.Role, hashAPIKey(apiKey.Key), apiKey.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to execute the API key INSERT statement. Err: %w", err)
+	}
+
+	ID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get the API key id. Err: %w", err)
+	}
+	apiKey.ID = int(ID)
+
+	return apiKey, nil
+}
+
+// RevokeAPIKey disables an API key, it's kept on the api_keys table.
+//
+// id: The API key id.
+//
+// Returns an error if any.
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, cancel := c.withTimeout(ctx, "RevokeAPIKey")
+	defer cancel()
+
+	result, err := c.DB.ExecContext(ctx, "UPDATE api_keys SET revoked_at=? WHERE id=? AND revoked_at IS NULL",
+		time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("Unable to execute the API key UPDATE statement. Err: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Unable to get the API key affected rows. Err: %w", err)
+	}
+
+	if affected == 0 {
+		return newError(ErrNotFound, "Unable to find an active API key with id: %d", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package artworks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestAuthenticator(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Errorf("Unable to generate a RSA key. Err: %s", err)
+		return
+	}
+
+	secret := []byte("s3cr3t")
+
+	authenticator := &Authenticator{
+		APIKeys:     &Client{DB: db},
+		HS256Secret: secret,
+		RS256Key:    &rsaKey.PublicKey,
+		Issuer:      "https://auth.example.org",
+		Audience:    "artworks-api",
+	}
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Errorf("Unable to sign a JWT token. Err: %s", err)
+		}
+		return token
+	}
+
+	claims := func(iss, aud string, exp time.Duration) jwt.MapClaims {
+		return jwt.MapClaims{"sub": "jcleira", "iss": iss, "aud": aud, "exp": time.Now().Add(exp).Unix()}
+	}
+
+	r := mux.NewRouter()
+	r.Use(authenticator.Middleware)
+	r.HandleFunc("/artworks", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(requestAuthor(r)))
+	}).Methods("GET", "OPTIONS")
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		method        string
+		header        string
+		value         string
+		apiKey        *APIKey
+		xAuthor       string
+		statusCode    int
+		author        string
+		invalidHeader bool
+	}{
+		{name: "no credentials", statusCode: http.StatusUnauthorized},
+		{name: "options", method: "OPTIONS", statusCode: http.StatusUnauthorized},
+		{name: "api key", header: APIKeyHeader, value: "d4t4-3ntry", apiKey: &APIKey{ID: 1, Name: "data-entry", Role: RoleEditor},
+			statusCode: http.StatusOK, author: "data-entry"},
+		{name: "forged author", header: APIKeyHeader, value: "d4t4-3ntry", apiKey: &APIKey{ID: 1, Name: "data-entry", Role: RoleEditor},
+			xAuthor: "jcleira", statusCode: http.StatusOK, author: "data-entry"},
+		{name: "unknown api key", header: APIKeyHeader, value: "unkn0wn", statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "basic auth", header: "Authorization", value: "Basic amNsZWlyYTpzM2NyM3Q=", statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "HS256", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS256, secret,
+			claims("https://auth.example.org", "artworks-api", time.Hour)), statusCode: http.StatusOK, author: "jcleira"},
+		{name: "RS256", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodRS256, rsaKey,
+			claims("https://auth.example.org", "artworks-api", time.Hour)), statusCode: http.StatusOK, author: "jcleira"},
+		{name: "wrong secret", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS256, []byte("other"),
+			claims("https://auth.example.org", "artworks-api", time.Hour)), statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "unexpected algorithm", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS512, secret,
+			claims("https://auth.example.org", "artworks-api", time.Hour)), statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "wrong issuer", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS256, secret,
+			claims("https://evil.example.org", "artworks-api", time.Hour)), statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "wrong audience", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS256, secret,
+			claims("https://auth.example.org", "other-api", time.Hour)), statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "expired", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS256, secret,
+			claims("https://auth.example.org", "artworks-api", -time.Hour)), statusCode: http.StatusUnauthorized, invalidHeader: true},
+		{name: "no expiration", header: "Authorization", value: "Bearer " + sign(jwt.SigningMethodHS256, secret,
+			jwt.MapClaims{"sub": "jcleira", "iss": "https://auth.example.org", "aud": "artworks-api"}),
+			statusCode: http.StatusUnauthorized, invalidHeader: true},
+	}
+
+	for _, test := range tests {
+		if test.header == APIKeyHeader {
+			rows := sqlmock.NewRows([]string{"id", "name", "role", "created_at"})
+			if test.apiKey != nil {
+				rows.AddRow(test.apiKey.ID, test.apiKey.Name, string(test.apiKey.Role), 1489140633)
+			}
+
+			mock.ExpectQuery("SELECT id, name, role, created_at FROM api_keys WHERE key_hash=\\? AND revoked_at IS NULL").
+				WithArgs(hashAPIKey(test.value)).
+				WillReturnRows(rows)
+		}
+
+		method := test.method
+		if method == "" {
+			method = "GET"
+		}
+
+		req, _ := http.NewRequest(method, server.URL+"/artworks", nil)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		if test.xAuthor != "" {
+			req.Header.Set("X-Author", test.xAuthor)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("Unable to perform the %s request. Err: %s", test.name, err)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.name, resp.StatusCode, test.statusCode)
+			continue
+		}
+
+		if test.statusCode == http.StatusUnauthorized {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			if !strings.HasPrefix(challenge, "Bearer") || strings.Contains(challenge, "invalid_token") != test.invalidHeader {
+				t.Errorf("The WWW-Authenticate header don't match for %s Got: %q", test.name, challenge)
+			}
+
+			if resp.Header.Get("Content-Type") != ProblemContentType {
+				t.Errorf("The %s response should be a problem. Got: %s", test.name, resp.Header.Get("Content-Type"))
+			}
+			continue
+		}
+
+		if string(body) != test.author {
+			t.Errorf("The request author don't match for %s Got: %q Expected: %q", test.name, body, test.author)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	mock.ExpectExec("INSERT INTO api_keys \\(name, role, key_hash, created_at\\) VALUES \\(\\?, \\?, \\?, \\?\\)").
+		WithArgs("data-entry", "editor", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("UPDATE api_keys SET revoked_at=\\? WHERE id=\\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE api_keys SET revoked_at=\\? WHERE id=\\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	apiKey, err := artworksClient.CreateAPIKey(context.Background(), " data-entry ", RoleEditor)
+	if err != nil {
+		t.Errorf("CreateAPIKey returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if apiKey.ID != 3 || apiKey.Name != "data-entry" || apiKey.Role != RoleEditor || len(apiKey.Key) != 64 {
+		t.Errorf("The created API key don't match Got: %+v", apiKey)
+	}
+
+	if _, err := artworksClient.CreateAPIKey(context.Background(), "", RoleEditor); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateAPIKey should return a validation error without a name. Got: %v", err)
+	}
+
+	if _, err := artworksClient.CreateAPIKey(context.Background(), "data-entry", Role("volunteer")); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateAPIKey should return a validation error with an unknown role. Got: %v", err)
+	}
+
+	if err := artworksClient.RevokeAPIKey(context.Background(), 3); err != nil {
+		t.Errorf("RevokeAPIKey returned a non expected error. Err: %s", err)
+	}
+
+	if err := artworksClient.RevokeAPIKey(context.Background(), 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RevokeAPIKey should return a not found error for a revoked key. Got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
@@ -1,8 +1,15 @@
 package artworks
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"image"
+	"io"
+	"log"
+	"reflect"
+	"time"
 )
 
 // Artwork is package's main struct, represents an Artwork information: (title,
@@ -19,120 +26,177 @@ import (
 //   Tecnica: '',
 // }
 type Artwork struct {
-	ID        int    `json:"id"`
-	Rei       string `json:"rei"`
-	CreatedAt int64  `json:"created_at"`
-	Ubi       string `json:"ubi"`
-	Pro       string `json:"pro"`
-	Adq       string `json:"adq"`
-	Reg       string `json:"reg"`
-	Nom       string `json:"nom"`
-	Tit       string `json:"tit"`
-	Aut       string `json:"aut"`
-	Fec       string `json:"fec"`
-	Lug       string `json:"lug"`
-	Ico       string `json:"ico"`
-	Tip       string `json:"tip"`
-	Tec       string `json:"tec"`
-	Sop       string `json:"sop"`
-	Mat       string `json:"mat"`
-	Tin       string `json:"tin"`
-	Dim       string `json:"dim"`
-	Hue       string `json:"hue"`
-	Ins       string `json:"ins"`
-	Des       string `json:"des"`
-	Est       string `json:"est"`
-	Uso       string `json:"uso"`
-	Prp       string `json:"prp"`
-	Vap       string `json:"vap"`
+	ID         int    `json:"id"`
+	Rei        string `json:"rei"`
+	CreatedAt  int64  `json:"created_at"`
+	Ubi        string `json:"ubi"`
+	Pro        string `json:"pro"`
+	Adq        string `json:"adq"`
+	Reg        string `json:"reg"`
+	Nom        string `json:"nom"`
+	Tit        string `json:"tit"`
+	Aut        string `json:"aut"`
+	Fec        string `json:"fec"`
+	Lug        string `json:"lug"`
+	Ico        string `json:"ico"`
+	Tip        string `json:"tip"`
+	Tec        string `json:"tec"`
+	Sop        string `json:"sop"`
+	Mat        string `json:"mat"`
+	Tin        string `json:"tin"`
+	Dim        string `json:"dim"`
+	Hue        string `json:"hue"`
+	Ins        string `json:"ins"`
+	Des        string `json:"des"`
+	Est        string `json:"est"`
+	Uso        string `json:"uso"`
+	Prp        string `json:"prp"`
+	Vap        string `json:"vap"`
+	Publicable bool   `json:"publicable"`
+	DeletedAt  *int64 `json:"deleted_at,omitempty"`
+	Version    int    `json:"version"`
 }
 
+// ErrVersionMismatch is returned when an Artwork change expected a version
+// other than the stored one, meaning someone else changed the Artwork first.
+var ErrVersionMismatch = errors.New("The Artwork has been changed by someone else")
+
 // Client is the Artworks struct that implements the ArtworksController
 // interface, it does also has the proper DB configuration to access the
-// Artworks data on the database.
+// Artworks data on the database and the Storage holding the Artworks images.
+// When Derivatives is set the images derivatives are generated on background
+// right after the upload, they are generated on demand otherwise.
+//
+// QueryTimeout bounds the database work of every Client call, zero means no
+// bound. Streamed listings and imports are only bound by the given context,
+// as they could take any time.
+//
+// MaxImagePixels is the maximum width × height of the uploaded images,
+// DefaultMaxImagePixels when it's not set.
 type Client struct {
-	DB *sql.DB
+	DB             *sql.DB
+	Storage        Storage
+	Derivatives    *DerivativeWorker
+	QueryTimeout   time.Duration
+	MaxImagePixels int
 }
 
 // ArtworksController interface define the required methods to implement
-// in order to be able to manage Artworks.
+// in order to be able to manage Artworks. Every method takes the request
+// context first, the database work is canceled along with it.
 type ArtworksController interface {
-	GetArtwork(int) (*Artwork, error)
-	GetArtworks() ([]Artwork, error)
-	AddUpdateArtwork(string, *Artwork) error
-	DeleteArtwork(int) error
+	GetArtwork(context.Context, int) (*Artwork, error)
+	GetArtworkByRei(context.Context, string) (*Artwork, error)
+	GetArtworks(context.Context) ([]Artwork, error)
+	QueryArtworks(context.Context, *ListOptions) (*ArtworksPage, error)
+	QueryArtworksPage(context.Context, *ListOptions) (*ArtworksPage, error)
+	WalkArtworks(context.Context, *ListOptions, func(*Artwork) error) error
+	SearchArtworks(context.Context, string, int) ([]SearchResult, error)
+	AddUpdateArtwork(context.Context, string, *Artwork, string) error
+	ImportArtworks(context.Context, []ImportRecord, string, bool) error
+	PatchArtwork(context.Context, int, *Artwork, []string, string) (*Artwork, error)
+	DeleteArtwork(context.Context, int, int, string) error
+	RestoreArtwork(context.Context, int, string) (*Artwork, error)
+	PurgeArtwork(context.Context, int, string) error
+	GetRevisions(context.Context, int) ([]Revision, error)
+	GetRevision(context.Context, int, int) (*Revision, error)
+	RestoreRevision(context.Context, int, int, int, string) (*Artwork, error)
+	AddImage(context.Context, *Image, io.Reader) error
+	GetImages(context.Context, int) ([]Image, error)
+	GetImage(context.Context, int, int) (*Image, error)
+	OpenImage(context.Context, *Image) (io.ReadCloser, error)
+	DecodeImage(context.Context, *Image) (image.Image, error)
+	OpenDerivative(context.Context, *Image, string, string) (io.ReadCloser, error)
+	DeleteImage(context.Context, int, int) error
+}
+
+// withTimeout returns a copy of ctx bound by the Client QueryTimeout, if any.
+// The returned cancel function should always be called, it records the
+// database work duration of the given Client method.
+func (c *Client) withTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	start := time.Now()
+
+	var cancel context.CancelFunc
+	if c.QueryTimeout <= 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, c.QueryTimeout)
+	}
+
+	return ctx, func() {
+		cancel()
+		observeQuery(method, start)
+	}
 }
 
-// GetArtwork returns an Artwork (by it's id) stored in the database.
+// queryer is implemented by both *sql.DB and *sql.Tx, it allows reading
+// Artworks either inside or outside a transaction.
+type queryer interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+// GetArtwork returns an Artwork (by it's id) stored in the database, deleted
+// Artworks are not returned.
 //
 // id - The Artwork id to query on the database.
 //
 // Returns:
 // An Artworks.
 // An error otherwise.
-func (c *Client) GetArtwork(id int) (*Artwork, error) {
-	rows, err := c.DB.Query("SELECT * FROM artworks WHERE id=?", id)
+func (c *Client) GetArtwork(ctx context.Context, id int) (*Artwork, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetArtwork")
+	defer cancel()
+
+	artwork, err := findArtwork(ctx, c.DB, "SELECT "+selectColumns+" FROM artworks WHERE id=? AND deleted_at IS NULL", id)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to query the artworks table. Err: %s", err)
+		return nil, err
 	}
 
-	defer rows.Close()
+	if artwork == nil {
+		return nil, newError(ErrNotFound, "Unable to find an Artwork with id: %d", id)
+	}
 
-	var artwork Artwork
+	return artwork, nil
+}
 
-	if rows.Next() {
-		err := rows.Scan(
-			&artwork.ID,
-			&artwork.Rei,
-			&artwork.CreatedAt,
-			&artwork.Ubi,
-			&artwork.Pro,
-			&artwork.Adq,
-			&artwork.Reg,
-			&artwork.Nom,
-			&artwork.Tit,
-			&artwork.Aut,
-			&artwork.Fec,
-			&artwork.Lug,
-			&artwork.Ico,
-			&artwork.Tip,
-			&artwork.Tec,
-			&artwork.Sop,
-			&artwork.Mat,
-			&artwork.Tin,
-			&artwork.Dim,
-			&artwork.Hue,
-			&artwork.Ins,
-			&artwork.Des,
-			&artwork.Est,
-			&artwork.Uso,
-			&artwork.Prp,
-			&artwork.Vap,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("Unable to map an Artwork data row. Err: %s", err)
-		}
-	} else {
-		return nil, fmt.Errorf("Unable to find an Artwork with id: %d", id)
+// GetArtworkByRei returns an Artwork (by it's rei, the museum registration
+// number) stored in the database, deleted Artworks are not returned.
+//
+// rei - The Artwork rei to query on the database.
+//
+// Returns:
+// An Artworks.
+// An error otherwise.
+func (c *Client) GetArtworkByRei(ctx context.Context, rei string) (*Artwork, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetArtworkByRei")
+	defer cancel()
+
+	artwork, err := findArtwork(ctx, c.DB, "SELECT "+selectColumns+" FROM artworks WHERE rei=? AND deleted_at IS NULL", rei)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %s", err)
+	if artwork == nil {
+		return nil, newError(ErrNotFound, "Unable to find an Artwork with rei: %s", rei)
 	}
 
-	return &artwork, nil
+	return artwork, nil
 }
 
-// GetArtworks returns all the Artworks stored in the database, it may become
-// slow as database grow another technique should be applied.
+// GetArtworks returns all the Artworks stored in the database but the deleted
+// ones, it may become slow as database grow, QueryArtworks or WalkArtworks
+// should be used for listings.
 //
 // Returns:
 // An array of Artworks.
 // An error otherwise.
-func (c *Client) GetArtworks() ([]Artwork, error) {
-	rows, err := c.DB.Query("SELECT * FROM artworks")
+func (c *Client) GetArtworks(ctx context.Context) ([]Artwork, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetArtworks")
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx, "SELECT "+selectColumns+" FROM artworks WHERE deleted_at IS NULL")
 	if err != nil {
-		return nil, fmt.Errorf("Unable to query the artworks table. Err: %s", err)
+		return nil, fmt.Errorf("Unable to query the artworks table. Err: %w", err)
 	}
 
 	defer rows.Close()
@@ -141,48 +205,260 @@ func (c *Client) GetArtworks() ([]Artwork, error) {
 
 	for rows.Next() {
 		var artwork Artwork
-		err := rows.Scan(
-			&artwork.ID,
-			&artwork.Rei,
-			&artwork.CreatedAt,
-			&artwork.Ubi,
-			&artwork.Pro,
-			&artwork.Adq,
-			&artwork.Reg,
-			&artwork.Nom,
-			&artwork.Tit,
-			&artwork.Aut,
-			&artwork.Fec,
-			&artwork.Lug,
-			&artwork.Ico,
-			&artwork.Tip,
-			&artwork.Tec,
-			&artwork.Sop,
-			&artwork.Mat,
-			&artwork.Tin,
-			&artwork.Dim,
-			&artwork.Hue,
-			&artwork.Ins,
-			&artwork.Des,
-			&artwork.Est,
-			&artwork.Uso,
-			&artwork.Prp,
-			&artwork.Vap,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("Unable to map an Artwork data row. Err: %s", err)
+		if err := scanArtwork(rows, &artwork); err != nil {
+			return nil, err
 		}
 
 		artworks = append(artworks, artwork)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %s", err)
+		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
 	}
 
 	return artworks, nil
 }
 
+// QueryArtworks returns a page of the Artworks stored in the database that
+// match the given ListOptions, along with the total amount of matching
+// Artworks and the cursor to fetch the next page.
+//
+// opts: The pagination, sorting and filtering settings.
+//
+// Returns:
+// An ArtworksPage.
+// An error otherwise.
+func (c *Client) QueryArtworks(ctx context.Context, opts *ListOptions) (*ArtworksPage, error) {
+	ctx, cancel := c.withTimeout(ctx, "QueryArtworks")
+	defer cancel()
+
+	where, args := opts.where()
+
+	page := ArtworksPage{
+		Artworks: make([]Artwork, 0),
+	}
+
+	err := c.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM artworks"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to count the artworks table. Err: %w", err)
+	}
+
+	after, afterArgs, err := opts.after()
+	if err != nil {
+		return nil, err
+	}
+
+	if after != "" {
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+
+	// We do fetch an extra row to know whether there is a next page.
+	args = append(args, opts.Limit+1, opts.Offset)
+
+	rows, err := c.DB.QueryContext(ctx, "SELECT "+selectColumns+" FROM artworks"+where+opts.orderBy()+" LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artworks table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var artwork Artwork
+		if err := scanArtwork(rows, &artwork); err != nil {
+			return nil, err
+		}
+
+		page.Artworks = append(page.Artworks, artwork)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
+	}
+
+	if len(page.Artworks) > opts.Limit {
+		page.Artworks = page.Artworks[:opts.Limit]
+		page.NextCursor = opts.nextCursor(&page.Artworks[opts.Limit-1])
+	}
+
+	return &page, nil
+}
+
+// QueryArtworksPage returns the ArtworksPage that QueryArtworks would return
+// for the given ListOptions but without its Artworks, so the page Artworks
+// could be streamed with WalkArtworks after sending the pagination details.
+//
+// opts: The pagination, sorting and filtering settings.
+//
+// Returns:
+// An ArtworksPage holding only the Total and NextCursor.
+// An error otherwise.
+func (c *Client) QueryArtworksPage(ctx context.Context, opts *ListOptions) (*ArtworksPage, error) {
+	ctx, cancel := c.withTimeout(ctx, "QueryArtworksPage")
+	defer cancel()
+
+	where, args := opts.where()
+
+	var page ArtworksPage
+
+	err := c.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM artworks"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to count the artworks table. Err: %w", err)
+	}
+
+	after, afterArgs, err := opts.after()
+	if err != nil {
+		return nil, err
+	}
+
+	if after != "" {
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+
+	// The page last Artwork and the next one, if any, tell the next cursor.
+	args = append(args, opts.Offset+opts.Limit-1)
+
+	rows, err := c.DB.QueryContext(ctx, "SELECT "+selectColumns+" FROM artworks"+where+opts.orderBy()+" LIMIT 2 OFFSET ?", args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artworks table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	var boundary []Artwork
+	for rows.Next() {
+		var artwork Artwork
+		if err := scanArtwork(rows, &artwork); err != nil {
+			return nil, err
+		}
+
+		boundary = append(boundary, artwork)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
+	}
+
+	if len(boundary) == 2 {
+		page.NextCursor = opts.nextCursor(&boundary[0])
+	}
+
+	return &page, nil
+}
+
+// WalkArtworks calls fn on every Artwork matching the given ListOptions, on
+// the ListOptions sort. The Artworks are read from the database as fn goes,
+// so they are never held in memory at once.
+//
+// When the ListOptions Limit is set only that page is walked, as
+// QueryArtworks does, every matching Artwork is walked otherwise.
+//
+// ctx: Cancels the walk, as when the client requesting the Artworks is gone.
+// opts: The pagination, sorting and filtering settings.
+// fn: Called on every Artwork, the Artwork can't be retained as it's reused,
+// walking stops on the first error returned.
+//
+// Returns an error if any, either the database one, the fn one or the ctx
+// one.
+func (c *Client) WalkArtworks(ctx context.Context, opts *ListOptions, fn func(*Artwork) error) error {
+	defer observeQuery("WalkArtworks", time.Now())
+
+	where, args := opts.where()
+
+	after, afterArgs, err := opts.after()
+	if err != nil {
+		return err
+	}
+
+	if after != "" {
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+
+	query := "SELECT " + selectColumns + " FROM artworks" + where + opts.orderBy()
+	if opts.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, opts.Limit, opts.Offset)
+	}
+
+	rows, err := c.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("Unable to query the artworks table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	var artwork Artwork
+	for rows.Next() {
+		// Rows might be already buffered, so canceling the query isn't enough.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		artwork = Artwork{}
+		if err := scanArtwork(rows, &artwork); err != nil {
+			return err
+		}
+
+		if err := fn(&artwork); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
+	}
+
+	return ctx.Err()
+}
+
+// SearchArtworks performs a full-text search on the Artworks descriptive
+// fields (tit, des, ico, ins and aut), results are sorted by relevance.
+//
+// Matching is accent-insensitive as it relies on the artworks table
+// collation, so 'Mahon' would match 'Mahón'.
+//
+// query: The words to search for.
+// limit: The maximum amount of results.
+//
+// Returns:
+// An array of SearchResults.
+// An error otherwise.
+func (c *Client) SearchArtworks(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	ctx, cancel := c.withTimeout(ctx, "SearchArtworks")
+	defer cancel()
+
+	match := fmt.Sprintf("MATCH(%s) AGAINST(? IN NATURAL LANGUAGE MODE)", searchColumns)
+
+	rows, err := c.DB.QueryContext(ctx,
+		fmt.Sprintf("SELECT %[1]s, %[2]s AS score FROM artworks WHERE %[2]s AND deleted_at IS NULL ORDER BY score DESC LIMIT ?", selectColumns, match),
+		query, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to search the artworks table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	results := make([]SearchResult, 0)
+
+	for rows.Next() {
+		var result SearchResult
+		if err := scanArtwork(rows, &result.Artwork, &result.Score); err != nil {
+			return nil, err
+		}
+
+		result.Highlights = highlight(&result.Artwork, query)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
+	}
+
+	return results, nil
+}
+
 // AddUpdateArtwork stores an Artwork by performing the action specified on the
 // action param.
 //
@@ -191,103 +467,428 @@ func (c *Client) GetArtworks() ([]Artwork, error) {
 // 'INSERT': For new Artworks.
 // 'UPDATE': For existing Artworks.
 //
-// It will return an error if the given action is not valid.
+// It will return an error if the given action is not valid, and an
+// ErrValidation one if the Artwork doesn't match the Artwork JSON Schema.
+//
+// On UPDATE, a non zero artwork Version should match the stored one, it will
+// return ErrVersionMismatch otherwise. The Version is increased on every
+// change.
+//
+// The change is recorded as a new Artwork Revision on the same transaction.
 //
 // action: One of the above.
 // artwork: The artwork to save.
+// author: Who performs the change.
 //
 // Returns an error if any.
-func (c *Client) AddUpdateArtwork(action string, artwork *Artwork) error {
-	sqlStatement := ""
+func (c *Client) AddUpdateArtwork(ctx context.Context, action string, artwork *Artwork, author string) error {
+	ctx, cancel := c.withTimeout(ctx, "AddUpdateArtwork")
+	defer cancel()
+
+	var sqlStatement string
+	var values []interface{}
+
 	switch action {
 	case "INSERT":
-		sqlStatement = fmt.Sprint(
-			"INSERT INTO artworks",
-			"(rei,ubi,pro,adq,reg,nom,tit,aut,fec,lug,ico,tip,tec,sop,mat,tin,dim,hue,ins,des,est,uso,prp,vap,created_at) ",
-			"VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? ,?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
-		break
+		sqlStatement = fmt.Sprintf(
+			"INSERT INTO artworks (%s) VALUES (%s)",
+			columnNames(insertColumns), placeholders(len(insertColumns)))
+		values = columnValues(insertColumns, artwork)
 	case "UPDATE":
-		sqlStatement = fmt.Sprint(
-			"UPDATE artworks SET ",
-			"rei=?,ubi=?,pro=?,",
-			"adq=?,reg=?,nom=?,tit=?,aut=?,fec=?,lug=?,ico=?,",
-			"tip=?,tec=?,sop=?,mat=?,tin=?,dim=?,hue=?,ins=?,",
-			"des=?,est=?,uso=?,prp=?,vap=? ",
-			"WHERE id=?")
-		break
+		sqlStatement = fmt.Sprintf(
+			"UPDATE artworks SET %s, version=version+1 WHERE id=?", columnAssignments(updateColumns))
+		values = append(columnValues(updateColumns, artwork), artwork.ID)
 	default:
 		return fmt.Errorf("The given action is not valid, it should be either INSERT or UPDATE")
 	}
 
-	stmt, err := c.DB.Prepare(sqlStatement)
-	if err != nil {
-		return fmt.Errorf("Unable to prepare the Artworks INSERT or UPDATE statement. Err: %s", err)
-	}
-
-	var values = []interface{}{
-		artwork.Rei,
-		artwork.Ubi,
-		artwork.Pro,
-		artwork.Adq,
-		artwork.Reg,
-		artwork.Nom,
-		artwork.Tit,
-		artwork.Aut,
-		artwork.Fec,
-		artwork.Lug,
-		artwork.Ico,
-		artwork.Tip,
-		artwork.Tec,
-		artwork.Sop,
-		artwork.Mat,
-		artwork.Tin,
-		artwork.Dim,
-		artwork.Hue,
-		artwork.Ins,
-		artwork.Des,
-		artwork.Est,
-		artwork.Uso,
-		artwork.Prp,
-		artwork.Vap,
+	if err := ValidateArtwork(artwork); err != nil {
+		return err
 	}
 
-	if action == "INSERT" {
-		values = append(values, artwork.CreatedAt)
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to begin the Artwork transaction. Err: %w", err)
 	}
+	defer tx.Rollback()
 
+	previous := &Artwork{}
 	if action == "UPDATE" {
-		values = append(values, artwork.ID)
+		previous, err = findArtwork(ctx, tx,
+			"SELECT "+selectColumns+" FROM artworks WHERE id=? AND deleted_at IS NULL FOR UPDATE", artwork.ID)
+		if err != nil {
+			return err
+		}
+
+		if previous == nil {
+			return newError(ErrNotFound, "Unable to find an Artwork with id: %d", artwork.ID)
+		}
+
+		if artwork.Version != 0 && artwork.Version != previous.Version {
+			return ErrVersionMismatch
+		}
+
+		artwork.CreatedAt = previous.CreatedAt
+		artwork.Version = previous.Version + 1
 	}
 
-	res, err := stmt.Exec(values...)
+	stmt, err := tx.PrepareContext(ctx, sqlStatement)
 	if err != nil {
-		return fmt.Errorf("Unable to execute the Artwork INSERT or UPDATE statement. Err: %s", err)
+		return fmt.Errorf("Unable to prepare the Artworks INSERT or UPDATE statement. Err: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, values...)
+	if isDuplicate(err) {
+		return conflictError(ctx, tx, artwork)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to execute the Artwork INSERT or UPDATE statement. Err: %w", err)
 	}
 
 	if action == "INSERT" {
-		// We are being permisive here with the error returned and we will return 0 as ID
-		// on the struct if we can't fetch the ID of the inserted item.
-		ID, _ := res.LastInsertId()
+		ID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("Unable to fetch the inserted Artwork ID. Err: %w", err)
+		}
 		artwork.ID = int(ID)
+		artwork.Version = 1
+	}
+
+	if err := recordRevision(ctx, tx, action, author, previous, artwork); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Unable to commit the Artwork transaction. Err: %w", err)
 	}
 
 	return nil
 }
 
-// DeleteArtwork deletes an Artwork from the database.
+// PatchArtwork updates only the given fields of an Artwork, so concurrent
+// changes on other fields are kept. The change is recorded as a new Artwork
+// Revision on the same transaction.
+//
+// A non zero patch Version should match the stored one, it will return
+// ErrVersionMismatch otherwise. The patched Artwork should match the Artwork
+// JSON Schema, it will return an ErrValidation error otherwise.
+//
+// ID: The ID of the Artwork to patch.
+// patch: An Artwork holding the new values of the patched fields and the
+// expected Version.
+// fields: The patched Artwork JSON field names.
+// author: Who performs the change.
+//
+// Returns:
+// The patched Artwork.
+// An error otherwise.
+func (c *Client) PatchArtwork(ctx context.Context, ID int, patch *Artwork, fields []string, author string) (*Artwork, error) {
+	ctx, cancel := c.withTimeout(ctx, "PatchArtwork")
+	defer cancel()
+
+	var cols []column
+	for _, name := range fields {
+		col, ok := patchableColumn(name)
+		if !ok {
+			return nil, newError(ErrValidation, "The field %s doesn't exist or can't be patched", name)
+		}
+		cols = append(cols, col)
+	}
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to begin the Artwork transaction. Err: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := findArtwork(ctx, tx,
+		"SELECT "+selectColumns+" FROM artworks WHERE id=? AND deleted_at IS NULL FOR UPDATE", ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == nil {
+		return nil, newError(ErrNotFound, "Unable to find an Artwork with id: %d", ID)
+	}
+
+	if patch.Version != 0 && patch.Version != previous.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	if len(cols) == 0 {
+		return previous, nil
+	}
+
+	current := *previous
+	current.Version++
+	for _, col := range cols {
+		reflect.ValueOf(col.field(&current)).Elem().Set(reflect.ValueOf(col.field(patch)).Elem())
+	}
+
+	if err := ValidateArtwork(&current); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "UPDATE artworks SET "+columnAssignments(cols)+", version=version+1 WHERE id=?")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to prepare the Artwork PATCH statement. Err: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, append(columnValues(cols, &current), ID)...)
+	if isDuplicate(err) {
+		return nil, conflictError(ctx, tx, &current)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to execute the Artwork PATCH statement. Err: %w", err)
+	}
+
+	if err := recordRevision(ctx, tx, "UPDATE", author, previous, &current); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Unable to commit the Artwork transaction. Err: %w", err)
+	}
+
+	return &current, nil
+}
+
+// DeleteArtwork moves an Artwork to the trash by setting its deletion date,
+// it could be recovered with RestoreArtwork until it gets purged. The
+// deletion is recorded as a new Artwork Revision on the same transaction.
 //
 // ID: The ID of the Artwork to delete.
+// version: The expected Artwork Version, zero to skip the check.
+// author: Who performs the deletion.
+//
+// Returns an error if any, ErrNotFound if the Artwork doesn't exist or
+// ErrVersionMismatch if the version don't match.
+func (c *Client) DeleteArtwork(ctx context.Context, ID int, version int, author string) error {
+	_, err := c.setDeletedAt(ctx, ID, version, "DELETE", author)
+	return err
+}
+
+// RestoreArtwork recovers an Artwork from the trash, the restoration is
+// recorded as a new Artwork Revision on the same transaction.
+//
+// ID: The ID of the Artwork to restore.
+// author: Who performs the restoration.
+//
+// Returns:
+// The restored Artwork.
+// An error otherwise.
+func (c *Client) RestoreArtwork(ctx context.Context, ID int, author string) (*Artwork, error) {
+	return c.setDeletedAt(ctx, ID, 0, "UNDELETE", author)
+}
+
+// PurgeArtwork deletes permanently an Artwork on the trash, including its
+// images, the Artwork history is kept and the purge is recorded as its last
+// Revision.
+//
+// ID: The ID of the Artwork to purge.
+// author: Who performs the purge.
 //
 // Returns an error if any.
-func (c *Client) DeleteArtwork(ID int) error {
-	stmt, err := c.DB.Prepare("DELETE FROM artworks WHERE id=?")
+func (c *Client) PurgeArtwork(ctx context.Context, ID int, author string) error {
+	ctx, cancel := c.withTimeout(ctx, "PurgeArtwork")
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("Unable to prepare the Artwork DELETE statement. Err: %s", err)
+		return fmt.Errorf("Unable to begin the Artwork transaction. Err: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(ID)
+	previous, err := findArtwork(ctx, tx,
+		"SELECT "+selectColumns+" FROM artworks WHERE id=? AND deleted_at IS NOT NULL FOR UPDATE", ID)
 	if err != nil {
-		return fmt.Errorf("Unable to execute the Artwork DELETE statement. Err: %s", err)
+		return err
+	}
+
+	if previous == nil {
+		return newError(ErrNotFound, "Unable to find an Artwork with id: %d on the trash", ID)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM artworks WHERE id=?")
+	if err != nil {
+		return fmt.Errorf("Unable to prepare the Artwork DELETE statement. Err: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("Unable to execute the Artwork DELETE statement. Err: %w", err)
+	}
+
+	keys, err := deleteImages(ctx, tx, ID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordRevision(ctx, tx, "PURGE", author, previous, &Artwork{ID: ID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Unable to commit the Artwork transaction. Err: %w", err)
+	}
+
+	// The Artwork is already purged, the images content left behind is only
+	// logged.
+	if err := c.deleteContents(keys); err != nil {
+		log.Printf("Unable to delete the Artwork %d images. Err: %s", ID, err)
+	}
+
+	return nil
+}
+
+// setDeletedAt moves an Artwork into the trash (DELETE action) or out of it
+// (UNDELETE action), recording the change as a new Artwork Revision.
+//
+// ID: The ID of the Artwork.
+// version: The expected Artwork Version, zero to skip the check.
+// action: Either DELETE or UNDELETE.
+// author: Who performs the change.
+//
+// Returns:
+// The changed Artwork.
+// An error otherwise.
+func (c *Client) setDeletedAt(ctx context.Context, ID int, version int, action string, author string) (*Artwork, error) {
+	method := "DeleteArtwork"
+	if action == "UNDELETE" {
+		method = "RestoreArtwork"
+	}
+
+	ctx, cancel := c.withTimeout(ctx, method)
+	defer cancel()
+
+	condition := "deleted_at IS NULL"
+	if action == "UNDELETE" {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to begin the Artwork transaction. Err: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := findArtwork(ctx, tx,
+		"SELECT "+selectColumns+" FROM artworks WHERE id=? AND "+condition+" FOR UPDATE", ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == nil {
+		if action == "DELETE" {
+			return nil, newError(ErrNotFound, "Unable to find an Artwork with id: %d", ID)
+		}
+		return nil, newError(ErrNotFound, "Unable to find an Artwork with id: %d on the trash", ID)
+	}
+
+	if version != 0 && version != previous.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	current := *previous
+	current.Version++
+	current.DeletedAt = nil
+	if action == "DELETE" {
+		deletedAt := time.Now().Unix()
+		current.DeletedAt = &deletedAt
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "UPDATE artworks SET deleted_at=?, version=version+1 WHERE id=?")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to prepare the Artwork %s statement. Err: %w", action, err)
+	}
+
+	_, err = stmt.ExecContext(ctx, current.DeletedAt, ID)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to execute the Artwork %s statement. Err: %w", action, err)
+	}
+
+	if err := recordRevision(ctx, tx, action, author, previous, &current); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Unable to commit the Artwork transaction. Err: %w", err)
+	}
+
+	return &current, nil
+}
+
+// conflictError returns the ErrConflict error for an Artwork write that
+// failed on a duplicated key, referring to the Artwork already using the rei
+// (deleted Artworks included) when found.
+//
+// q: The database or transaction to query.
+// artwork: The Artwork that failed to be written.
+//
+// Returns an ErrConflict error, or an error querying the artworks table.
+func conflictError(ctx context.Context, q queryer, artwork *Artwork) error {
+	existing, err := findArtwork(ctx, q, "SELECT "+selectColumns+" FROM artworks WHERE rei=? AND id<>?", artwork.Rei, artwork.ID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return newError(ErrConflict, "The Artwork conflicts with an existing one")
+	}
+
+	return &Error{
+		Kind:      ErrConflict,
+		Detail:    fmt.Sprintf("The rei %s is already used by the Artwork with id: %d", artwork.Rei, existing.ID),
+		ArtworkID: existing.ID,
+	}
+}
+
+// findArtwork returns the first Artwork returned by the given query, the
+// query should select the selectColumns.
+//
+// q: The database or transaction to query.
+// query: The SELECT statement.
+// args: The query arguments.
+//
+// Returns:
+// An Artwork, nil if the query returned no rows.
+// An error otherwise.
+func findArtwork(ctx context.Context, q queryer, query string, args ...interface{}) (*Artwork, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artworks table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
+		}
+		return nil, nil
+	}
+
+	var artwork Artwork
+	if err := scanArtwork(rows, &artwork); err != nil {
+		return nil, err
+	}
+
+	return &artwork, nil
+}
+
+// scanArtwork maps the current data row into the given Artwork, the row
+// columns are expected to be the selectColumns ones.
+//
+// rows: The data rows, positioned on the row to map.
+// artwork: The Artwork to fill.
+// extra: Destinations for any column selected after the Artwork ones.
+//
+// Returns an error if any.
+func scanArtwork(rows *sql.Rows, artwork *Artwork, extra ...interface{}) error {
+	dest := columnValues(columns, artwork)
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return fmt.Errorf("Unable to map an Artwork data row. Err: %w", err)
 	}
 
 	return nil
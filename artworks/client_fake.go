@@ -1,39 +1,155 @@
 package artworks
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
 
 // FakeClient implements the ArtworksController interface, as the 'real'
 // artworks.Client struct. It has been created for testing purposes.
-type FakeClient struct{}
+//
+// MaxImagePixels is honoured on AddImage as the Client one.
+type FakeClient struct {
+	MaxImagePixels int
+}
 
 // GetArtwork returns a mocked Artwork if a valid date has been
-// given.
-func (tc *FakeClient) GetArtwork(id int64) (Artwork, error) {
-	return Artwork{
-		ID:        1,
-		Rei:       "#EU82REE",
-		CreatedAt: 1489140631,
+// given, it's only publicable when the id 1 is requested.
+func (tc *FakeClient) GetArtwork(ctx context.Context, id int) (*Artwork, error) {
+	return &Artwork{
+		ID:         1,
+		Rei:        "#EU82REE",
+		CreatedAt:  1489140631,
+		Ubi:        "Sala 3",
+		Vap:        "12000",
+		Publicable: id == 1,
+		Version:    1,
 	}, nil
 }
 
+// GetArtworkByRei returns the mocked Artwork if its rei has been given,
+// ErrNotFound otherwise.
+func (tc *FakeClient) GetArtworkByRei(ctx context.Context, rei string) (*Artwork, error) {
+	if rei != "#EU82REE" {
+		return nil, newError(ErrNotFound, "Unable to find an Artwork with rei: %s", rei)
+	}
+
+	return tc.GetArtwork(ctx, 1)
+}
+
 // GetArtworks return an array of mocked Artwork if a valid date has been
 // given.
-func (tc *FakeClient) GetArtworks() ([]Artwork, error) {
+func (tc *FakeClient) GetArtworks(ctx context.Context) ([]Artwork, error) {
 	return []Artwork{
 		{
 			ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631,
+			Tit: "Vista del puerto de Mahón", Ubi: "Sala 3", Publicable: true,
 		},
 		{
 			ID: 2, Rei: "#F423432", CreatedAt: 1489140633,
+			Tit: "Retrato de dama",
 		},
 	}, nil
 }
 
+// listed returns the mocked Artworks listed by the given ListOptions, the
+// trash is always empty and public listings only hold the publicable ones.
+func (tc *FakeClient) listed(ctx context.Context, opts *ListOptions) []Artwork {
+	artworks, _ := tc.GetArtworks(ctx)
+
+	listed := []Artwork{}
+	for _, artwork := range artworks {
+		if !opts.Deleted && (!opts.Public || artwork.Publicable) {
+			listed = append(listed, artwork)
+		}
+	}
+
+	return listed
+}
+
+// QueryArtworks return a page with the mocked Artworks, the given ListOptions
+// limit is honoured to allow testing pagination. The trash is always empty.
+func (tc *FakeClient) QueryArtworks(ctx context.Context, opts *ListOptions) (*ArtworksPage, error) {
+	artworks := tc.listed(ctx, opts)
+
+	page := ArtworksPage{
+		Artworks: artworks,
+		Total:    len(artworks),
+	}
+
+	if len(page.Artworks) > opts.Limit {
+		page.Artworks = page.Artworks[:opts.Limit]
+		page.NextCursor = opts.nextCursor(&page.Artworks[opts.Limit-1])
+	}
+
+	return &page, nil
+}
+
+// QueryArtworksPage return the mocked Artworks page without its Artworks.
+func (tc *FakeClient) QueryArtworksPage(ctx context.Context, opts *ListOptions) (*ArtworksPage, error) {
+	page, _ := tc.QueryArtworks(ctx, opts)
+	page.Artworks = nil
+
+	return page, nil
+}
+
+// WalkArtworks calls fn on the mocked Artworks, the given ListOptions limit
+// is honoured as QueryArtworks does. The trash is always empty.
+func (tc *FakeClient) WalkArtworks(ctx context.Context, opts *ListOptions, fn func(*Artwork) error) error {
+	artworks := tc.listed(ctx, opts)
+	if opts.Limit > 0 && len(artworks) > opts.Limit {
+		artworks = artworks[:opts.Limit]
+	}
+
+	for i := range artworks {
+		if err := fn(&artworks[i]); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// SearchArtworks return the mocked Artworks whose descriptive fields match the
+// given query, highlighted as the real client does.
+func (tc *FakeClient) SearchArtworks(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	artworks, _ := tc.GetArtworks(ctx)
+
+	results := make([]SearchResult, 0)
+	for _, artwork := range artworks {
+		highlights := highlight(&artwork, query)
+		if len(highlights) > 0 && len(results) < limit {
+			results = append(results, SearchResult{
+				Artwork: artwork, Score: 1, Highlights: highlights,
+			})
+		}
+	}
+
+	return results, nil
+}
+
 // AddUpdateArtwork return nil if the proper action was sent, error otherwise.
-func (tc *FakeClient) AddUpdateArtwork(action string, artwork *Artwork) error {
+// Inserts using the mocked Artwork rei return an ErrConflict error, updates
+// expecting a version other than the mocked one return ErrVersionMismatch.
+func (tc *FakeClient) AddUpdateArtwork(ctx context.Context, action string, artwork *Artwork, author string) error {
 	switch action {
 	case "INSERT":
+		if artwork.Rei == "#EU82REE" {
+			return &Error{
+				Kind:      ErrConflict,
+				Detail:    fmt.Sprintf("The rei %s is already used by the Artwork with id: %d", artwork.Rei, 1),
+				ArtworkID: 1,
+			}
+		}
 	case "UPDATE":
+		if artwork.Version > 1 {
+			return ErrVersionMismatch
+		}
 		return nil
 	default:
 		return fmt.Errorf("The given action is not valid, it should be either INSERT or UPDATE")
@@ -42,7 +158,201 @@ func (tc *FakeClient) AddUpdateArtwork(action string, artwork *Artwork) error {
 	return nil
 }
 
-// DeleteArtwork return always nil.
-func (tc *FakeClient) DeleteArtwork(ID int) error {
+// ImportArtworks returns an ErrConflict error holding the rows using the
+// mocked Artwork rei, it sets sequential ids from 2 on the given Artworks
+// otherwise, unless on a dry run.
+func (tc *FakeClient) ImportArtworks(ctx context.Context, records []ImportRecord, author string, dryRun bool) error {
+	var failures []FieldError
+	for _, record := range records {
+		if reiKey(record.Artwork.Rei) == reiKey("#EU82REE") {
+			failures = append(failures, FieldError{
+				Row:     record.Row,
+				Field:   "rei",
+				Message: fmt.Sprintf("The rei %s is already used by the Artwork with id: %d", record.Artwork.Rei, 1),
+			})
+		}
+	}
+
+	if len(failures) > 0 {
+		return &Error{Kind: ErrConflict, Detail: "The import has rei already in use", Fields: failures}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	for i, record := range records {
+		record.Artwork.ID = i + 2
+		record.Artwork.Version = 1
+	}
+
 	return nil
 }
+
+// PatchArtwork return the mocked Artwork with the patched fields applied,
+// ErrVersionMismatch if the mocked Artwork version is not expected and an
+// ErrValidation error if the patched Artwork is not valid.
+func (tc *FakeClient) PatchArtwork(ctx context.Context, ID int, patch *Artwork, fields []string, author string) (*Artwork, error) {
+	artwork, _ := tc.GetArtwork(ctx, ID)
+	if patch.Version > 1 {
+		return nil, ErrVersionMismatch
+	}
+	artwork.Version++
+
+	for _, name := range fields {
+		col, ok := patchableColumn(name)
+		if !ok {
+			return nil, newError(ErrValidation, "The field %s doesn't exist or can't be patched", name)
+		}
+		reflect.ValueOf(col.field(artwork)).Elem().Set(reflect.ValueOf(col.field(patch)).Elem())
+	}
+
+	if err := ValidateArtwork(artwork); err != nil {
+		return nil, err
+	}
+
+	return artwork, nil
+}
+
+// DeleteArtwork return nil if the mocked Artwork version is expected,
+// ErrVersionMismatch otherwise.
+func (tc *FakeClient) DeleteArtwork(ctx context.Context, ID int, version int, author string) error {
+	if version > 1 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// RestoreArtwork return the mocked Artwork.
+func (tc *FakeClient) RestoreArtwork(ctx context.Context, ID int, author string) (*Artwork, error) {
+	return tc.GetArtwork(ctx, ID)
+}
+
+// PurgeArtwork return always nil.
+func (tc *FakeClient) PurgeArtwork(ctx context.Context, ID int, author string) error {
+	return nil
+}
+
+// GetRevisions return the mocked history of an Artwork, a single INSERT.
+func (tc *FakeClient) GetRevisions(ctx context.Context, artworkID int) ([]Revision, error) {
+	return []Revision{
+		{
+			Rev: 1, ArtworkID: artworkID, Action: "INSERT", Author: "anonymous", CreatedAt: 1489140631,
+			Changes: map[string]Change{"rei": {From: "", To: "#EU82REE"}, "ubi": {From: "", To: "Sala 3"}},
+		},
+	}, nil
+}
+
+// GetRevision return the mocked Revision if the first one is requested,
+// error otherwise.
+func (tc *FakeClient) GetRevision(ctx context.Context, artworkID int, rev int) (*Revision, error) {
+	if rev != 1 {
+		return nil, newError(ErrNotFound, "Unable to find the Revision %d of the Artwork with id: %d", rev, artworkID)
+	}
+
+	revisions, _ := tc.GetRevisions(ctx, artworkID)
+	artwork, _ := tc.GetArtwork(ctx, artworkID)
+
+	revision := revisions[0]
+	revision.Artwork = artwork
+
+	return &revision, nil
+}
+
+// RestoreRevision return the mocked Revision Artwork, ErrVersionMismatch if
+// the mocked Artwork version is not expected.
+func (tc *FakeClient) RestoreRevision(ctx context.Context, artworkID int, rev int, version int, author string) (*Artwork, error) {
+	if version > 1 {
+		return nil, ErrVersionMismatch
+	}
+
+	revision, err := tc.GetRevision(ctx, artworkID, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision.Artwork, nil
+}
+
+// AddImage drains the image content and sets the mocked Image ID and size,
+// ErrImageTooLarge if the content exceeds MaxImageSize and an ErrValidation
+// error if it exceeds MaxImagePixels.
+func (tc *FakeClient) AddImage(ctx context.Context, image *Image, content io.Reader) error {
+	checked, err := checkImagePixels(&maxSizeReader{r: content, remaining: MaxImageSize}, tc.MaxImagePixels)
+	if err != nil {
+		return err
+	}
+
+	size, err := io.Copy(ioutil.Discard, checked)
+	if err != nil {
+		return err
+	}
+
+	image.ID = 1
+	image.Size = size
+
+	return nil
+}
+
+// GetImages return an array with the mocked Image.
+func (tc *FakeClient) GetImages(ctx context.Context, artworkID int) ([]Image, error) {
+	image, _ := tc.GetImage(ctx, artworkID, 1)
+
+	return []Image{*image}, nil
+}
+
+// GetImage return the mocked Image if its id has been given, ErrNotFound
+// otherwise.
+func (tc *FakeClient) GetImage(ctx context.Context, artworkID int, imageID int) (*Image, error) {
+	if imageID != 1 {
+		return nil, newError(ErrNotFound, "Unable to find the Image %d of the Artwork with id: %d", imageID, artworkID)
+	}
+
+	return &Image{
+		ID:          1,
+		ArtworkID:   artworkID,
+		Filename:    "puerto-mahon-frontal.png",
+		ContentType: "image/png",
+		Size:        int64(len(fakeImageContent)),
+		CreatedAt:   1489140631,
+	}, nil
+}
+
+// OpenImage return the mocked Image content.
+func (tc *FakeClient) OpenImage(ctx context.Context, image *Image) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(fakeImageContent)), nil
+}
+
+// DecodeImage return the decoded mocked Image content, an error if it exceeds
+// MaxImagePixels.
+func (tc *FakeClient) DecodeImage(ctx context.Context, img *Image) (image.Image, error) {
+	return decodeImageContent(strings.NewReader(fakeImageContent), tc.MaxImagePixels)
+}
+
+// OpenDerivative return the mocked Image content for the valid sizes and
+// formats, an ErrValidation error otherwise.
+func (tc *FakeClient) OpenDerivative(ctx context.Context, image *Image, size string, format string) (io.ReadCloser, error) {
+	if _, ok := DerivativeSizes[size]; !ok {
+		return nil, newError(ErrValidation, "The image size %s is not valid", size)
+	}
+
+	if _, ok := derivativeFormats[format]; !ok {
+		return nil, newError(ErrValidation, "The image format %s is not supported", format)
+	}
+
+	return tc.OpenImage(ctx, image)
+}
+
+// DeleteImage return nil if the mocked Image id has been given, ErrNotFound
+// otherwise.
+func (tc *FakeClient) DeleteImage(ctx context.Context, artworkID int, imageID int) error {
+	_, err := tc.GetImage(ctx, artworkID, imageID)
+	return err
+}
+
+// fakeImageContent is the mocked Image content, a red 4x2 PNG image.
+const fakeImageContent = "\x89\x50\x4e\x47\x0d\x0a\x1a\x0a\x00\x00\x00\x0d\x49\x48\x44\x52\x00\x00\x00\x04\x00\x00\x00\x02" +
+	"\x08\x02\x00\x00\x00\xf0\xca\xea\x34\x00\x00\x00\x10\x49\x44\x41\x54\x78\xda\x63\xf8\xcf\xc0\x00" +
+	"\x47\x0c\xc8\x1c\x00\x6f\xaa\x07\xf9\x68\xdd\xaf\xa7\x00\x00\x00\x00\x49\x45\x4e\x44\xae\x42\x60" +
+	"\x82"
@@ -1,215 +1,157 @@
 package artworks
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
-func TestCheckValidDate(t *testing.T) {
-	tests := []struct {
-		date          int64
-		expectedError error
-	}{
-		{
-			date:          1489140630, // A valid date
-			expectedError: nil,
-		},
-		{
-			date:          1480000000, // A non valid date
-			expectedError: fmt.Errorf("Unable to query Snapshots from over one month"),
-		},
-		{
-			date:          -1, // A non valid date
-			expectedError: fmt.Errorf("Unable to query Snapshots from over one month"),
-		},
-	}
-
-	for _, test := range tests {
-		err := ValidateDate(test.date)
-		if test.expectedError != nil {
-			if err.Error() != test.expectedError.Error() {
-				t.Errorf("The returned error or no error from GetSnapshots don't math the test case. Got: %s Expected: %s", err, test.expectedError)
-				return
-			}
-		}
+func TestGetArtwork(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	expected := &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631, Tit: "Vista del puerto de Mahón"}
+
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, expected)...))
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")))
+
+	artwork, err := artworksClient.GetArtwork(context.Background(), 1)
+	if err != nil {
+		t.Errorf("GetArtwork returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if !reflect.DeepEqual(artwork, expected) {
+		t.Errorf("The returned Artwork don't match the expected. Got: %+v Expected: %+v", artwork, expected)
+	}
+
+	if _, err := artworksClient.GetArtwork(context.Background(), 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetArtwork returned a non expected error. Got: %v Expected: %s", err, ErrNotFound)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
 	}
 }
 
-func TestGetSnapshots(t *testing.T) {
+func TestGetArtworks(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Errorf("Unable to open a stub database connection. Err %s", err)
 	}
-
 	defer db.Close()
 
-	snapshotsClient := Client{
+	artworksClient := Client{
 		DB: db,
 	}
 
-	tests := []struct {
-		date              int64
-		expectedSnapshots []Snapshot
-		expectedError     error
-	}{
-		{
-			date: 1489140630, // A valid date
-			expectedSnapshots: []Snapshot{
-				{
-					ID: 1, Code: "#EU82REE", CreatedAt: 1489140631, Cups: 2839, Wins: 232,
-					Card1: "giant_skeleton", Card2: "goblins_barrel", Card3: "pekka", Card4: "princess",
-					Card5: "ice_wizard", Card6: "rocket", Card7: "mortar", Card8: "prince",
-				},
-				{
-					ID: 2, Code: "#F423432", CreatedAt: 1489140633, Cups: 3839, Wins: 432,
-					Card1: "royal_giant", Card2: "princess", Card3: "poison", Card4: "miner",
-					Card5: "furnace", Card6: "rage", Card7: "zap", Card8: "prince",
-				},
-			},
-			expectedError: nil,
-		},
-		{
-			date:              1480000000, // A non valid date
-			expectedSnapshots: nil,
-			expectedError:     fmt.Errorf("Unable to query Snapshots from over one month"),
-		},
-	}
-
-	for _, test := range tests {
-		columns := []string{
-			"id", "code", "created_at", "cups", "wins",
-			"card_1", "card_2", "card_3", "card_4",
-			"card_5", "card_6", "card_7", "card_8"}
-
-		mock.ExpectQuery("SELECT (.+) FROM player_snapshots where created_at >= ?").
-			WithArgs(test.date).
-			WillReturnRows(sqlmock.NewRows(columns).
-				AddRow(1, "#EU82REE", 1489140631, 2839, 232,
-					"giant_skeleton", "goblins_barrel", "pekka", "princess",
-					"ice_wizard", "rocket", "mortar", "prince").
-				AddRow(2, "#F423432", 1489140633, 3839, 432,
-					"royal_giant", "princess", "poison", "miner",
-					"furnace", "rage", "zap", "prince"))
-
-		snapshots, err := snapshotsClient.GetSnapshots(test.date)
-		if test.expectedError != nil {
-			if err.Error() != test.expectedError.Error() {
-				t.Errorf("Returned error from GetSnapshots don't math the test case. Got: %s Expected: %s", err, test.expectedError)
-				return
-			}
-		}
-
-		if !reflect.DeepEqual(snapshots, test.expectedSnapshots) {
-			t.Errorf("The returned Snapshots from GetSnapshots() don't match the expected. Got: %v Expected: %v", snapshots, test.expectedSnapshots)
-		}
-
-		if test.expectedError == nil {
-			if err := mock.ExpectationsWereMet(); err != nil {
-				t.Errorf("There were unfulfilled expections: %s", err)
-				return
-			}
-		}
+	expected := []Artwork{
+		{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631},
+		{ID: 2, Rei: "#F423432", CreatedAt: 1489140633},
 	}
-}
 
-func TestAddUpdateSnapshot(t *testing.T) {
-	tests := []struct {
-		action        string
-		snapshot      *Snapshot
-		expectedError error
-	}{
-		{
-			action: "INSERT",
-			snapshot: &Snapshot{
-				Code: "#EU82REE", CreatedAt: 1489140631, Cups: 2839, Wins: 232,
-				Card1: "giant_skeleton", Card2: "goblins_barrel", Card3: "pekka", Card4: "princess",
-				Card5: "ice_wizard", Card6: "rocket", Card7: "mortar", Card8: "prince",
-			},
-			expectedError: nil,
-		},
-		{
-			action: "UPDATE",
-			snapshot: &Snapshot{
-				ID: 2, Code: "#F423432", CreatedAt: 1489140633, Cups: 3839, Wins: 432,
-				Card1: "royal_giant", Card2: "princess", Card3: "poison", Card4: "miner",
-				Card5: "furnace", Card6: "rage", Card7: "zap", Card8: "prince",
-			},
-			expectedError: nil,
-		},
-		{
-			action:        "FOO",
-			expectedError: fmt.Errorf("The given action is not valid, it should be either INSERT or UPDATE"),
-		},
+	mock.ExpectQuery("SELECT (.+) FROM artworks").
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, &expected[0])...).
+			AddRow(columnArgs(columns, &expected[1])...))
+
+	artworks, err := artworksClient.GetArtworks(context.Background())
+	if err != nil {
+		t.Errorf("GetArtworks returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if !reflect.DeepEqual(artworks, expected) {
+		t.Errorf("The returned Artworks don't match the expected. Got: %+v Expected: %+v", artworks, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
 	}
+}
 
+func TestAddUpdateArtwork(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Errorf("Unable to open a stub database connection. Err %s", err)
 	}
 	defer db.Close()
 
-	snapshotsClient := Client{
+	artworksClient := Client{
 		DB: db,
 	}
 
-	for _, test := range tests {
-		if test.expectedError == nil {
-			switch test.action {
-			case "INSERT":
-				mock.ExpectPrepare("INSERT INTO player_snapshots").ExpectExec().WithArgs(
-					test.snapshot.Code, test.snapshot.Cups, test.snapshot.Wins,
-					test.snapshot.Card1, test.snapshot.Card2, test.snapshot.Card3,
-					test.snapshot.Card4, test.snapshot.Card5, test.snapshot.Card6,
-					test.snapshot.Card7, test.snapshot.Card8, test.snapshot.CreatedAt).
-					WillReturnResult(sqlmock.NewResult(1, 1))
-				break
-			case "UPDATE:":
-				mock.ExpectPrepare("UPDATE player_snapshots SET").ExpectExec().WithArgs(
-					test.snapshot.Code, test.snapshot.Cups, test.snapshot.Wins,
-					test.snapshot.Card1, test.snapshot.Card2, test.snapshot.Card3,
-					test.snapshot.Card4, test.snapshot.Card5, test.snapshot.Card6,
-					test.snapshot.Card7, test.snapshot.Card8).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-			}
-		}
-
-		err = snapshotsClient.AddUpdateSnapshot(test.action, test.snapshot)
-		if test.expectedError != nil {
-			if err.Error() != test.expectedError.Error() {
-				t.Errorf("The returned error or no error from AddUpdateSnapshot don't math the test case. Got: %s Expected: %s", err, test.expectedError)
-				return
-			}
-		}
-
-		if test.expectedError == nil {
-			if err := mock.ExpectationsWereMet(); err != nil {
-				t.Errorf("There were unfulfilled expections: %s", err)
-				return
-			}
-		}
+	artwork := &Artwork{Rei: "#F423433", Tit: "Retrato de caballero", CreatedAt: 1489140635}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO artworks").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO artwork_revisions").
+		WithArgs(3, 1, "INSERT", "jcleira", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := artworksClient.AddUpdateArtwork(context.Background(), "INSERT", artwork, "jcleira"); err != nil {
+		t.Errorf("AddUpdateArtwork returned a non expected error on INSERT. Err: %s", err)
+		return
+	}
+
+	if artwork.ID != 3 {
+		t.Errorf("The inserted Artwork ID don't match Got: %d Expected: 3", artwork.ID)
+	}
+
+	if err := artworksClient.AddUpdateArtwork(context.Background(), "UPSERT", artwork, "jcleira"); err == nil {
+		t.Errorf("AddUpdateArtwork should fail with a non valid action")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
 	}
 }
 
-func TestDeleteSnapshot(t *testing.T) {
+func TestDeleteArtwork(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Errorf("Unable to open a stub database connection. Err %s", err)
 	}
 	defer db.Close()
 
-	snapshotsClient := Client{
+	artworksClient := Client{
 		DB: db,
 	}
 
-	mock.ExpectPrepare("DELETE FROM player_snapshots").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Deleting a missing Artwork is a not found error, no Revision is recorded.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\? AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")))
+	mock.ExpectRollback()
 
-	err = snapshotsClient.DeleteSnapshot(1)
-	if err != nil {
-		t.Errorf("DeleteSnapshot returned a non expected error. Err: %s", err)
-		return
+	if err := artworksClient.DeleteArtwork(context.Background(), 1, 0, "jcleira"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteArtwork returned a non expected error. Got: %v Expected: %s", err, ErrNotFound)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
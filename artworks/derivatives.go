@@ -0,0 +1,203 @@
+package artworks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"github.com/gen2brain/webp"
+)
+
+// DerivativeSizes are the available image derivatives by name, the value is
+// the maximum width and height in pixels. Images are never enlarged.
+var DerivativeSizes = map[string]int{
+	"thumb":  200,
+	"medium": 800,
+	"large":  1600,
+}
+
+// derivativeFormat encodes the image derivatives on a given format.
+type derivativeFormat struct {
+	contentType string
+	encode      func(io.Writer, image.Image) error
+}
+
+// derivativeFormats are the available image derivatives formats by name.
+// The WebP encoder is pure Go, libwebp compiled to WebAssembly, so it doesn't
+// require cgo.
+var derivativeFormats = map[string]derivativeFormat{
+	"jpeg": {
+		contentType: "image/jpeg",
+		encode: func(w io.Writer, img image.Image) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+		},
+	},
+	"webp": {
+		contentType: "image/webp",
+		encode: func(w io.Writer, img image.Image) error {
+			return webp.Encode(w, img, webp.Options{Quality: 80})
+		},
+	},
+}
+
+// DerivativeWorker generates the image derivatives on background, so the
+// uploads don't wait for them. The derivatives are stored next to the
+// original image, on the same Storage.
+//
+// MaxImagePixels is the maximum width × height of the decoded images,
+// DefaultMaxImagePixels when it's not set.
+type DerivativeWorker struct {
+	MaxImagePixels int
+
+	storage Storage
+	queue   chan Image
+	wg      sync.WaitGroup
+}
+
+// NewDerivativeWorker returns a DerivativeWorker, it won't generate any
+// derivative until started.
+//
+// storage: The Storage holding the Artworks images.
+// queueSize: The amount of images waiting for derivatives, images enqueued
+// on a full queue get their derivatives on demand.
+//
+// Returns a DerivativeWorker.
+func NewDerivativeWorker(storage Storage, queueSize int) *DerivativeWorker {
+	return &DerivativeWorker{
+		storage: storage,
+		queue:   make(chan Image, queueSize),
+	}
+}
+
+// Start runs the given amount of goroutines generating derivatives.
+func (dw *DerivativeWorker) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		dw.wg.Add(1)
+		go func() {
+			defer dw.wg.Done()
+
+			for image := range dw.queue {
+				if err := generateDerivatives(dw.storage, &image, dw.MaxImagePixels); err != nil {
+					log.Printf("Unable to generate the Image %d derivatives. Err: %s", image.ID, err)
+				}
+			}
+		}()
+	}
+}
+
+// Enqueue adds an image to the derivatives queue without blocking.
+//
+// image: The uploaded Image.
+//
+// Returns whether the image has been enqueued.
+func (dw *DerivativeWorker) Enqueue(image Image) bool {
+	select {
+	case dw.queue <- image:
+		return true
+	default:
+		log.Printf("The derivatives queue is full, skipping the Image %d", image.ID)
+		return false
+	}
+}
+
+// Close stops accepting images and waits until the enqueued ones get their
+// derivatives.
+func (dw *DerivativeWorker) Close() {
+	close(dw.queue)
+	dw.wg.Wait()
+}
+
+// OpenDerivative opens an image derivative from the Client Storage, it's
+// generated on demand when it's not there yet.
+//
+// image: The original Image.
+// size: One of the DerivativeSizes names.
+// format: One of the derivativeFormats names.
+//
+// Returns:
+// The derivative content, it should be closed by the caller.
+// An error otherwise, an ErrValidation one if the size or format don't exist.
+func (c *Client) OpenDerivative(ctx context.Context, image *Image, size string, format string) (io.ReadCloser, error) {
+	if _, ok := DerivativeSizes[size]; !ok {
+		return nil, newError(ErrValidation, "The image size %s is not valid", size)
+	}
+
+	if _, ok := derivativeFormats[format]; !ok {
+		return nil, newError(ErrValidation, "The image format %s is not supported", format)
+	}
+
+	content, err := c.Storage.Get(derivativeKey(image.StorageKey, size, format))
+	if !errors.Is(err, ErrNotFound) {
+		return content, err
+	}
+
+	if err := generateDerivatives(c.Storage, image, c.MaxImagePixels); err != nil {
+		return nil, err
+	}
+
+	return c.Storage.Get(derivativeKey(image.StorageKey, size, format))
+}
+
+// generateDerivatives stores every size and format derivative of an image.
+// The EXIF orientation is applied and, as the derivatives are encoded from
+// the decoded pixels, any metadata is stripped.
+//
+// storage: The Storage holding the Artworks images.
+// image: The original Image.
+// maxPixels: The maximum width × height of the original Image, as on
+// checkImagePixels.
+//
+// Returns an error if any.
+func generateDerivatives(storage Storage, image *Image, maxPixels int) error {
+	content, err := storage.Get(image.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	original, err := decodeImageContent(content, maxPixels)
+	if err != nil {
+		return fmt.Errorf("Unable to decode the Image. Err: %s", err)
+	}
+
+	for size, max := range DerivativeSizes {
+		derivative := imaging.Fit(original, max, max, imaging.Lanczos)
+
+		for name, format := range derivativeFormats {
+			var buf bytes.Buffer
+			if err := format.encode(&buf, derivative); err != nil {
+				return fmt.Errorf("Unable to encode the Image %s %s derivative. Err: %s", size, name, err)
+			}
+
+			if _, err := storage.Put(derivativeKey(image.StorageKey, size, name), &buf); err != nil {
+				return fmt.Errorf("Unable to store the Image %s %s derivative. Err: %s", size, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// derivativeKey returns the Storage key of an image derivative.
+func derivativeKey(key string, size string, format string) string {
+	return fmt.Sprintf("%s-%s.%s", key, size, format)
+}
+
+// derivativeKeys returns the Storage keys of every derivative of an image.
+func derivativeKeys(key string) []string {
+	var keys []string
+	for size := range DerivativeSizes {
+		for format := range derivativeFormats {
+			keys = append(keys, derivativeKey(key, size, format))
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,148 @@
+package artworks
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gen2brain/webp"
+	"github.com/gorilla/mux"
+)
+
+// orientedJPEG returns a JPEG image with the given size and an EXIF
+// orientation tag.
+func orientedJPEG(width, height int, orientation byte) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil)
+
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08" +
+		"\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00" + string(orientation) + "\x00\x00" +
+		"\x00\x00\x00\x00")
+	app1 := append([]byte{0xff, 0xe1, 0x00, byte(len(exif) + 2)}, exif...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestGenerateDerivatives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artworks-images")
+	if err != nil {
+		t.Errorf("Unable to create the storage directory. Err: %s", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	storage := &LocalStorage{Dir: dir}
+	img := &Image{ID: 1, ArtworkID: 1, StorageKey: "artworks/1/image"}
+
+	// Orientation 6 rotates the image 90 degrees, so the 400x200 image is
+	// shown as a 200x400 one.
+	storage.Put(img.StorageKey, bytes.NewReader(orientedJPEG(400, 200, 6)))
+
+	if err := generateDerivatives(storage, img, 0); err != nil {
+		t.Errorf("generateDerivatives returned a non expected error. Err: %s", err)
+		return
+	}
+
+	tests := map[string]image.Point{
+		"thumb":  {100, 200},
+		"medium": {200, 400},
+		"large":  {200, 400},
+	}
+
+	decoders := map[string]func(io.Reader) (image.Config, error){
+		"jpeg": jpeg.DecodeConfig,
+		"webp": webp.DecodeConfig,
+	}
+
+	for size, expected := range tests {
+		for format, decode := range decoders {
+			content, err := storage.Get(derivativeKey(img.StorageKey, size, format))
+			if err != nil {
+				t.Errorf("Unable to open the %s %s derivative. Err: %s", size, format, err)
+				continue
+			}
+
+			data, _ := ioutil.ReadAll(content)
+			content.Close()
+
+			config, err := decode(bytes.NewReader(data))
+			if err != nil || config.Width != expected.X || config.Height != expected.Y {
+				t.Errorf("The %s %s derivative size don't match Got: %dx%d Expected: %dx%d",
+					size, format, config.Width, config.Height, expected.X, expected.Y)
+			}
+
+			if bytes.Contains(data, []byte("Exif")) {
+				t.Errorf("The %s %s derivative should not have EXIF metadata", size, format)
+			}
+		}
+	}
+}
+
+func TestDerivativeWorker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artworks-images")
+	if err != nil {
+		t.Errorf("Unable to create the storage directory. Err: %s", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	storage := &LocalStorage{Dir: dir}
+	storage.Put("artworks/1/image", bytes.NewReader(orientedJPEG(400, 200, 1)))
+
+	derivatives := NewDerivativeWorker(storage, 1)
+	derivatives.Start(1)
+
+	if !derivatives.Enqueue(Image{ID: 1, ArtworkID: 1, StorageKey: "artworks/1/image"}) {
+		t.Errorf("The image should be enqueued")
+	}
+	derivatives.Close()
+
+	for _, key := range derivativeKeys("artworks/1/image") {
+		if _, err := os.Stat(storage.path(key)); err != nil {
+			t.Errorf("The derivative %s should be generated. Err: %s", key, err)
+		}
+	}
+}
+
+func TestGetImageHandlerDerivatives(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}/images/{image:[0-9]+}", ProblemHandler(GetImageHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		params      string
+		statusCode  int
+		contentType string
+	}{
+		{params: "?size=thumb", statusCode: http.StatusOK, contentType: "image/jpeg"},
+		{params: "?size=medium&format=jpeg", statusCode: http.StatusOK, contentType: "image/jpeg"},
+		{params: "?size=huge", statusCode: http.StatusUnprocessableEntity},
+		{params: "?size=thumb&format=bmp", statusCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + "/artworks/1/images/1" + test.params)
+		if err != nil {
+			t.Errorf("Unable to perform GetImage request. Err: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.params, resp.StatusCode, test.statusCode)
+		}
+
+		if test.contentType != "" && resp.Header.Get("Content-Type") != test.contentType {
+			t.Errorf("The response Content-Type don't match for %s Got: %s Expected: %s", test.params, resp.Header.Get("Content-Type"), test.contentType)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package artworks
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jcleira/handler/handler"
+)
+
+// ProblemContentType is the RFC 7807 problem details media type.
+const ProblemContentType = "application/problem+json"
+
+var (
+	// ErrNotFound is the kind of the errors returned when an Artwork (or any of
+	// its resources) doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is the kind of the errors returned when a change conflicts
+	// with the stored data, as duplicated values.
+	ErrConflict = errors.New("conflict")
+
+	// ErrValidation is the kind of the errors returned when the given data is
+	// not valid.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrUnauthenticated is the kind of the errors returned when a request
+	// credentials are missing or not valid.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden is the kind of the errors returned when the request
+	// Principal role is not allowed to perform an operation.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Error is an artworks error safe to be shown to the API users, Kind is one
+// of ErrNotFound, ErrConflict, ErrValidation, ErrUnauthenticated or
+// ErrForbidden so it could be checked with errors.Is. Any other error is
+// considered internal and it's never shown.
+// Fields holds the failures by field of ErrValidation errors, if known.
+// ArtworkID is the Artwork the error refers to, as the conflicting one on
+// ErrConflict errors, if known.
+type Error struct {
+	Kind      error
+	Detail    string
+	Fields    []FieldError
+	ArtworkID int
+}
+
+// Error returns the error detail.
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// Unwrap returns the error kind.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// newError returns an Error of the given kind with a formatted detail.
+func newError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Detail: fmt.Sprintf(format, args...)}
+}
+
+// isDuplicate tells whether the given database error is a duplicated key
+// one.
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// isUnavailable tells whether the given error is due to the database not
+// being reachable, or to the request being canceled while waiting for it.
+func isUnavailable(err error) bool {
+	var netErr *net.OpError
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, sql.ErrConnDone) || errors.Is(err, context.Canceled) || errors.As(err, &netErr)
+}
+
+// Problem is a RFC 7807 problem details response body, validation problems
+// extend it with the failures by field and conflict ones with the conflicting
+// Artwork id.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	ArtworkID int          `json:"artwork_id,omitempty"`
+}
+
+// httpError returns a HTTPError for the given error, its status is given by
+// the error kind or the fallback one for any other error. Database timeouts
+// are 504 Gateway Timeout and an unreachable database 503 Service
+// Unavailable.
+//
+// err: The error to return.
+// fallback: The status for errors without a kind.
+//
+// Returns a HTTPError ready to be returned by a CustomHandler.
+func httpError(err error, fallback int) *handler.HTTPError {
+	status := fallback
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, ErrValidation):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, ErrUnauthenticated):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrVersionMismatch):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, ErrImageTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	case isUnavailable(err):
+		status = http.StatusServiceUnavailable
+	}
+
+	return &handler.HTTPError{err, status}
+}
+
+// ProblemHandler wraps a CustomHandler so its errors are written as RFC 7807
+// problem details. Server errors are logged and their details are replaced
+// by a generic message, so database internals never reach the API users.
+//
+// h: The CustomHandler to wrap.
+//
+// Returns a http.Handler ready to be added to a HTTP server / router.
+func ProblemHandler(h handler.CustomHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpErr := h(w, r)
+		if httpErr == nil {
+			return
+		}
+
+		detail := httpErr.Err.Error()
+		if httpErr.Status >= http.StatusInternalServerError {
+			log.Printf("%s %s failed. Err: %s", r.Method, r.URL.Path, httpErr.Err)
+
+			switch httpErr.Status {
+			case http.StatusServiceUnavailable:
+				detail = "The database is not available, the request could be retried later"
+			case http.StatusGatewayTimeout:
+				detail = "The database took too long to answer, the request could be retried later"
+			default:
+				detail = "The request could not be completed due to an internal error"
+			}
+		}
+
+		problem := Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(httpErr.Status),
+			Status:   httpErr.Status,
+			Detail:   detail,
+			Instance: r.URL.Path,
+		}
+
+		var artworksErr *Error
+		if errors.As(httpErr.Err, &artworksErr) {
+			problem.Errors = artworksErr.Fields
+			problem.ArtworkID = artworksErr.ArtworkID
+		}
+
+		writeProblem(w, problem)
+	})
+}
+
+// writeProblem writes the given Problem as the response.
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,121 @@
+package artworks
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	"github.com/jcleira/handler/handler"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		err        error
+		statusCode int
+	}{
+		{err: newError(ErrNotFound, "Unable to find an Artwork with id: %d", 1), statusCode: http.StatusNotFound},
+		{err: newError(ErrConflict, "The Artwork conflicts with an existing one"), statusCode: http.StatusConflict},
+		{err: newError(ErrValidation, "The field %s doesn't exist or can't be patched", "id"), statusCode: http.StatusUnprocessableEntity},
+		{err: ErrVersionMismatch, statusCode: http.StatusPreconditionFailed},
+		{err: errors.New("Unable to query the artworks table"), statusCode: http.StatusInternalServerError},
+		{err: fmt.Errorf("Unable to query the artworks table. Err: %w", context.DeadlineExceeded), statusCode: http.StatusGatewayTimeout},
+		{err: fmt.Errorf("Unable to query the artworks table. Err: %w", driver.ErrBadConn), statusCode: http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		if httpErr := httpError(test.err, http.StatusInternalServerError); httpErr.Status != test.statusCode {
+			t.Errorf("The status for %q don't match Got: %d Expected: %d", test.err, httpErr.Status, test.statusCode)
+		}
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB:           db,
+		QueryTimeout: time.Nanosecond,
+	}
+
+	_, err = artworksClient.GetArtwork(context.Background(), 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetArtwork should fail once the query timeout is exceeded. Got: %v", err)
+	}
+
+	if httpErr := httpError(err, http.StatusInternalServerError); httpErr.Status != http.StatusGatewayTimeout {
+		t.Errorf("The status for a query timeout don't match Got: %d Expected: %d", httpErr.Status, http.StatusGatewayTimeout)
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	if !isDuplicate(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}) {
+		t.Errorf("A duplicated entry error should be detected as such")
+	}
+
+	if isDuplicate(&mysql.MySQLError{Number: 1064}) || isDuplicate(nil) {
+		t.Errorf("Only duplicated entry errors should be detected as such")
+	}
+}
+
+func TestProblemHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/missing", ProblemHandler(func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		return httpError(newError(ErrNotFound, "Unable to find an Artwork with id: %d", 2), http.StatusInternalServerError)
+	}))
+	r.Handle("/failing", ProblemHandler(func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		return httpError(errors.New("Error 1045: Access denied for user 'artworks'"), http.StatusInternalServerError)
+	}))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		path       string
+		statusCode int
+		detail     string
+	}{
+		{path: "/missing", statusCode: http.StatusNotFound, detail: "Unable to find an Artwork with id: 2"},
+		{path: "/failing", statusCode: http.StatusInternalServerError, detail: "The request could not be completed due to an internal error"},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + test.path)
+		if err != nil {
+			t.Errorf("Unable to perform the request. Err: %s", err)
+			return
+		}
+
+		var problem Problem
+		err = json.NewDecoder(resp.Body).Decode(&problem)
+		resp.Body.Close()
+		if err != nil {
+			t.Errorf("Unable to decode the problem details. Err: %s", err)
+			return
+		}
+
+		if resp.StatusCode != test.statusCode || problem.Status != test.statusCode {
+			t.Errorf("The response status don't match Got: %d Expected: %d", resp.StatusCode, test.statusCode)
+		}
+
+		if resp.Header.Get("Content-Type") != ProblemContentType {
+			t.Errorf("The response Content-Type don't match Got: %s Expected: %s", resp.Header.Get("Content-Type"), ProblemContentType)
+		}
+
+		if problem.Detail != test.detail || problem.Instance != test.path {
+			t.Errorf("The problem details don't match the expected. Got: %+v", problem)
+		}
+	}
+}
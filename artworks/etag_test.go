@@ -0,0 +1,94 @@
+package artworks
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestGetArtworkHandlerETag(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}", GetArtworkHandler(&FakeClient{}))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		ifNoneMatch string
+		statusCode  int
+	}{
+		{ifNoneMatch: "", statusCode: http.StatusOK},
+		{ifNoneMatch: `"1"`, statusCode: http.StatusNotModified},
+		{ifNoneMatch: `W/"1"`, statusCode: http.StatusNotModified},
+		{ifNoneMatch: `"2", "3"`, statusCode: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprint(server.URL, "/artworks/1"), nil)
+		if test.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("Unable to perform GetArtwork request. Err: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response status code don't match the expected. Got: %d Expected: %d", resp.StatusCode, test.statusCode)
+		}
+
+		if resp.Header.Get("ETag") != `"1"` {
+			t.Errorf("The response ETag don't match the expected. Got: %s Expected: \"1\"", resp.Header.Get("ETag"))
+		}
+	}
+}
+
+func TestIfMatchHandlers(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}", UpdateArtworkHandler(&FakeClient{})).Methods("PUT")
+	r.Handle("/artworks/{id:[0-9]+}", PatchArtworkHandler(&FakeClient{})).Methods("PATCH")
+	r.Handle("/artworks/{id:[0-9]+}", DeleteArtworkHandler(&FakeClient{})).Methods("DELETE")
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		method     string
+		body       string
+		ifMatch    string
+		statusCode int
+	}{
+		{method: http.MethodPut, body: `{"id": 1, "rei": "#EU82REE"}`, ifMatch: `"1"`, statusCode: http.StatusNoContent},
+		{method: http.MethodPut, body: `{"id": 1, "rei": "#EU82REE"}`, ifMatch: `"2"`, statusCode: http.StatusPreconditionFailed},
+		{method: http.MethodPut, body: `{"id": 1, "rei": "#EU82REE"}`, ifMatch: `foo`, statusCode: http.StatusPreconditionFailed},
+		{method: http.MethodPatch, body: `{"est": "Malo"}`, ifMatch: `"1"`, statusCode: http.StatusOK},
+		{method: http.MethodPatch, body: `{"est": "Malo"}`, ifMatch: `"2"`, statusCode: http.StatusPreconditionFailed},
+		{method: http.MethodDelete, ifMatch: "", statusCode: http.StatusNoContent},
+		{method: http.MethodDelete, ifMatch: `"2"`, statusCode: http.StatusPreconditionFailed},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, fmt.Sprint(server.URL, "/artworks/1"), bytes.NewBufferString(test.body))
+		if test.ifMatch != "" {
+			req.Header.Set("If-Match", test.ifMatch)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("Unable to perform %s request. Err: %s", test.method, err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The %s response status code don't match the expected. Got: %d Expected: %d", test.method, resp.StatusCode, test.statusCode)
+		}
+	}
+}
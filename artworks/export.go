@@ -0,0 +1,164 @@
+package artworks
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// exportColumns are the columns written on the CSV and XLSX exports, the
+// deletion date and version are only meaningful to the API.
+var exportColumns = columnsExcept(columns, "deleted_at", "version")
+
+// ExportLabels are the human-readable Spanish headers of the CSV and XLSX
+// exports, by Artwork JSON field name.
+var ExportLabels = map[string]string{
+	"id":         "Id",
+	"rei":        "Referencia",
+	"created_at": "Fecha de alta",
+	"ubi":        "Ubicación",
+	"pro":        "Procedencia",
+	"adq":        "Adquisición",
+	"reg":        "Registro",
+	"nom":        "Objeto",
+	"tit":        "Título",
+	"aut":        "Autor",
+	"fec":        "Fecha",
+	"lug":        "Lugar",
+	"ico":        "Iconografía",
+	"tip":        "Tipología",
+	"tec":        "Técnica",
+	"sop":        "Soporte",
+	"mat":        "Materia",
+	"tin":        "Tinta",
+	"dim":        "Dimensiones",
+	"hue":        "Huella",
+	"ins":        "Inscripciones",
+	"des":        "Descripción",
+	"est":        "Estado de conservación",
+	"uso":        "Uso",
+	"prp":        "Propietario",
+	"vap":        "Valoración",
+	"publicable": "Publicable",
+}
+
+// exportFormat writes the Artworks exports on a given format.
+type exportFormat struct {
+	contentType string
+	newWriter   func(io.Writer) (artworksWriter, error)
+}
+
+// exportFormats are the available Artworks export formats by name, the name
+// is also the exported file extension.
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		newWriter:   newCSVExportWriter,
+	},
+	"xlsx": {
+		contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		newWriter:   newXLSXExportWriter,
+	},
+	"jsonl": {
+		contentType: NDJSONContentType,
+		newWriter:   newNDJSONWriter,
+	},
+}
+
+// exportHeader returns the exports header row.
+func exportHeader() []interface{} {
+	header := make([]interface{}, len(exportColumns))
+	for i, col := range exportColumns {
+		header[i] = ExportLabels[col.name]
+	}
+
+	return header
+}
+
+// exportRow returns the given Artwork exported values, the creation date is
+// written as a date, flags as Sí / No and the rest of numeric columns as
+// int64.
+func exportRow(artwork *Artwork) []interface{} {
+	row := make([]interface{}, len(exportColumns))
+	for i, value := range columnValues(exportColumns, artwork) {
+		switch v := value.(type) {
+		case *int:
+			row[i] = int64(*v)
+		case *int64:
+			row[i] = time.Unix(*v, 0).UTC().Format("2006-01-02")
+		case *bool:
+			row[i] = "No"
+			if *v {
+				row[i] = "Sí"
+			}
+		case *string:
+			row[i] = *v
+		}
+	}
+
+	return row
+}
+
+// csvExportWriter writes the Artworks as CSV rows, the file starts with a
+// UTF-8 BOM so spreadsheets don't garble the accents.
+type csvExportWriter struct {
+	csv *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) (artworksWriter, error) {
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return nil, fmt.Errorf("Unable to write the CSV export. Err: %s", err)
+	}
+
+	cw := &csvExportWriter{csv: csv.NewWriter(w)}
+	return cw, cw.write(exportHeader())
+}
+
+func (cw *csvExportWriter) Write(artwork *Artwork) error {
+	return cw.write(exportRow(artwork))
+}
+
+func (cw *csvExportWriter) write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = fmt.Sprint(value)
+	}
+
+	if err := cw.csv.Write(record); err != nil {
+		return fmt.Errorf("Unable to write the CSV export. Err: %s", err)
+	}
+
+	return nil
+}
+
+func (cw *csvExportWriter) Close() error {
+	cw.csv.Flush()
+	if err := cw.csv.Error(); err != nil {
+		return fmt.Errorf("Unable to write the CSV export. Err: %s", err)
+	}
+
+	return nil
+}
+
+// xlsxExportWriter writes the Artworks as the rows of a XLSX sheet.
+type xlsxExportWriter struct {
+	xlsx *xlsxWriter
+}
+
+func newXLSXExportWriter(w io.Writer) (artworksWriter, error) {
+	xw, err := newXLSXWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return &xlsxExportWriter{xlsx: xw}, xw.Write(exportHeader())
+}
+
+func (xw *xlsxExportWriter) Write(artwork *Artwork) error {
+	return xw.xlsx.Write(exportRow(artwork))
+}
+
+func (xw *xlsxExportWriter) Close() error {
+	return xw.xlsx.Close()
+}
@@ -0,0 +1,151 @@
+package artworks
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestWalkArtworks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	opts := NewListOptions()
+	opts.Limit = 0
+	opts.Sort = "tit"
+	opts.Filters["est"] = "Bueno"
+
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE deleted_at IS NULL AND est=\\? ORDER BY tit ASC, id ASC$").
+		WithArgs("Bueno").
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, &Artwork{ID: 2, Rei: "#F423432", Tit: "Retrato de dama"})...).
+			AddRow(columnArgs(columns, &Artwork{ID: 1, Rei: "#EU82REE", Tit: "Vista del puerto de Mahón"})...))
+
+	var reis []string
+	err = artworksClient.WalkArtworks(context.Background(), opts, func(artwork *Artwork) error {
+		reis = append(reis, artwork.Rei)
+		return errors.New("stop")
+	})
+
+	if err == nil || err.Error() != "stop" || len(reis) != 1 || reis[0] != "#F423432" {
+		t.Errorf("WalkArtworks should stop on the first fn error. Got: %v %v", reis, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestExportArtworksHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/export", ProblemHandler(ExportArtworksHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		format     string
+		statusCode int
+	}{
+		{format: "csv", statusCode: http.StatusOK},
+		{format: "xlsx", statusCode: http.StatusOK},
+		{format: "jsonl", statusCode: http.StatusOK},
+		{format: "pdf", statusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + "/artworks/export?sort=-id&format=" + test.format)
+		if err != nil {
+			t.Errorf("Unable to perform ExportArtworks request. Err: %s", err)
+			return
+		}
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.format, resp.StatusCode, test.statusCode)
+			continue
+		}
+
+		if test.statusCode != http.StatusOK {
+			continue
+		}
+
+		if resp.Header.Get("Content-Type") != exportFormats[test.format].contentType {
+			t.Errorf("The response Content-Type don't match for %s Got: %s", test.format, resp.Header.Get("Content-Type"))
+		}
+
+		if !strings.Contains(resp.Header.Get("Content-Disposition"), "artworks."+test.format) {
+			t.Errorf("The response Content-Disposition don't match for %s Got: %s", test.format, resp.Header.Get("Content-Disposition"))
+		}
+
+		switch test.format {
+		case "csv":
+			records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))).ReadAll()
+			if err != nil || len(records) != 3 || records[0][8] != "Título" || records[2][8] != "Retrato de dama" || records[2][2] != "2017-03-10" {
+				t.Errorf("The CSV export don't match the mocked Artworks. Got: %v Err: %v", records, err)
+			}
+		case "xlsx":
+			sheet := readXLSXSheet(t, data)
+			if !strings.Contains(sheet, "<t xml:space=\"preserve\">Título</t>") || !strings.Contains(sheet, "Vista del puerto de Mahón") {
+				t.Errorf("The XLSX export don't match the mocked Artworks. Got: %s", sheet)
+			}
+		case "jsonl":
+			var lines int
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				var artwork Artwork
+				if err := json.Unmarshal(scanner.Bytes(), &artwork); err != nil || artwork.ID == 0 {
+					t.Errorf("The JSON Lines export line don't match an Artwork. Got: %s", scanner.Text())
+				}
+				lines++
+			}
+
+			if lines != 2 {
+				t.Errorf("The JSON Lines export don't match the mocked Artworks. Got: %d lines", lines)
+			}
+		}
+	}
+}
+
+// readXLSXSheet returns the XLSX workbook sheet XML.
+func readXLSXSheet(t *testing.T, data []byte) string {
+	workbook, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Errorf("Unable to open the XLSX workbook. Err: %s", err)
+		return ""
+	}
+
+	for _, f := range workbook.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			content, _ := f.Open()
+			sheet, _ := ioutil.ReadAll(content)
+			content.Close()
+
+			return string(sheet)
+		}
+	}
+
+	t.Errorf("The XLSX workbook should have a sheet")
+	return ""
+}
@@ -1,11 +1,19 @@
 package artworks
 
 import (
-	"database/sql"
+	"bufio"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -13,26 +21,88 @@ import (
 )
 
 // ConfigureHandlers is meant to be called by the server.go main routine.
-// It will configure the artworks package handlers, currently it configures the
-// database connection the json schemas for validation and the router.
+// It will configure the artworks package handlers on the router.
+//
+// Every handler is wrapped by ProblemHandler, so errors are written as RFC
+// 7807 problem details, and by Authorize with the permission the route
+// requires. The router should use the Authenticator Middleware.
 //
 // r: The HTTP server *mux.Router to be configured.
-// db: The database connection to use.
+// artworksClient: The Client holding the database connection, the images
+// Storage and DerivativeWorker and the query timeout.
 //
 // Returns nothing.
-func ConfigureHandlers(r *mux.Router, db *sql.DB) {
-	artworksClient := &Client{
-		DB: db,
-	}
+func ConfigureHandlers(r *mux.Router, artworksClient *Client) {
+	r.Handle("/artworks", ProblemHandler(Authorize(PermRead, GetArtworksHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/export", ProblemHandler(Authorize(PermRead, ExportArtworksHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/search", ProblemHandler(Authorize(PermRead, SearchArtworksHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/schema", ProblemHandler(Authorize(PermRead, GetSchemaHandler()))).Methods("GET")
+	r.Handle("/artworks/by-rei/{rei}", ProblemHandler(Authorize(PermRead, GetArtworkByReiHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/trash", ProblemHandler(Authorize(PermDelete, GetTrashHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks", ProblemHandler(Authorize(PermWrite, AddArtworkHandler(artworksClient)))).Methods("PUT")
+	r.Handle("/artworks/import", ProblemHandler(Authorize(PermWrite, ImportArtworksHandler(artworksClient)))).Methods("POST")
+	r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermRead, GetArtworkHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermWrite, UpdateArtworkHandler(artworksClient)))).Methods("PUT")
+	r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermWrite, PatchArtworkHandler(artworksClient)))).Methods("PATCH")
+	r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermDelete, DeleteArtworkHandler(artworksClient)))).Methods("DELETE")
+	r.Handle("/artworks/{id:[0-9]+}/restore", ProblemHandler(Authorize(PermDelete, RestoreArtworkHandler(artworksClient)))).Methods("POST")
+	r.Handle("/artworks/{id:[0-9]+}/history", ProblemHandler(Authorize(PermRead, GetRevisionsHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/{id:[0-9]+}/history/{rev:[0-9]+}", ProblemHandler(Authorize(PermRead, GetRevisionHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/{id:[0-9]+}/history/{rev:[0-9]+}/restore", ProblemHandler(Authorize(PermWrite, RestoreRevisionHandler(artworksClient)))).Methods("POST")
+	r.Handle("/artworks/{id:[0-9]+}/images", ProblemHandler(Authorize(PermWrite, AddImageHandler(artworksClient)))).Methods("POST")
+	r.Handle("/artworks/{id:[0-9]+}/images", ProblemHandler(Authorize(PermRead, GetImagesHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/{id:[0-9]+}/images/{image:[0-9]+}", ProblemHandler(Authorize(PermRead, GetImageHandler(artworksClient)))).Methods("GET")
+	r.Handle("/artworks/{id:[0-9]+}/images/{image:[0-9]+}", ProblemHandler(Authorize(PermDelete, DeleteImageHandler(artworksClient)))).Methods("DELETE")
+	r.Handle("/iiif/artworks/{id:[0-9]+}/manifest.json", ProblemHandler(Authorize(PermRead, GetIIIFManifestHandler(artworksClient)))).Methods("GET")
+	r.Handle("/iiif/artworks/{id:[0-9]+}/images/{image:[0-9]+}", ProblemHandler(Authorize(PermRead, GetIIIFServiceHandler()))).Methods("GET")
+	r.Handle("/iiif/artworks/{id:[0-9]+}/images/{image:[0-9]+}/info.json", ProblemHandler(Authorize(PermRead, GetIIIFInfoHandler(artworksClient)))).Methods("GET")
+	r.Handle("/iiif/artworks/{id:[0-9]+}/images/{image:[0-9]+}/{region}/{size}/{rotation}/{quality:[a-z]+}.{format:[a-z]+}",
+		ProblemHandler(Authorize(PermRead, GetIIIFImageHandler(artworksClient)))).Methods("GET")
+}
+
+// ConfigurePublicHandlers is meant to be called by the server.go main
+// routine, it configures the public read-only API. Its routes don't require
+// authentication, so they should be registered outside the Authenticator
+// Middleware, and they only serve the given fields of the publicable
+// Artworks.
+//
+// r: The HTTP server *mux.Router to be configured.
+// artworksClient: The Client holding the database connection.
+// fields: The public Artwork fields, see DefaultPublicFields and
+// ParsePublicFields.
+//
+// Returns nothing.
+func ConfigurePublicHandlers(r *mux.Router, artworksClient *Client, fields []string) {
+	r.Handle("/public/artworks", ProblemHandler(GetPublicArtworksHandler(artworksClient, fields))).Methods("GET")
+	r.Handle("/public/artworks/{id:[0-9]+}", ProblemHandler(GetPublicArtworkHandler(artworksClient, fields))).Methods("GET")
+}
 
-	r.Handle("/artworks", GetArtworksHandler(artworksClient)).Methods("GET")
-	r.Handle("/artworks", AddArtworkHandler(artworksClient)).Methods("PUT", "OPTIONS")
-	r.Handle("/artworks/{id:[0-9]+}", GetArtworkHandler(artworksClient)).Methods("GET")
-	r.Handle("/artworks/{id:[0-9]+}", UpdateArtworkHandler(artworksClient)).Methods("PUT", "OPTIONS")
-	r.Handle("/artworks/{id:[0-9]+}", DeleteArtworkHandler(artworksClient)).Methods("DELETE")
+// ConfigureAdminHandlers is meant to be called by the server.go main routine
+// with the admin router, it's served on its own listener so the operations
+// that can't be undone are never exposed along with the public API. The
+// routes are only allowed to the roles granted PermAdmin.
+//
+// r: The admin HTTP server *mux.Router to be configured.
+// artworksClient: The Client holding the database connection and the images
+// Storage.
+//
+// Returns nothing.
+func ConfigureAdminHandlers(r *mux.Router, artworksClient *Client) {
+	r.Handle("/artworks/trash/{id:[0-9]+}", ProblemHandler(Authorize(PermAdmin, PurgeArtworkHandler(artworksClient)))).Methods("DELETE")
+	r.Handle("/api-keys", ProblemHandler(Authorize(PermAdmin, CreateAPIKeyHandler(artworksClient)))).Methods("POST")
+	r.Handle("/api-keys/{id:[0-9]+}", ProblemHandler(Authorize(PermAdmin, RevokeAPIKeyHandler(artworksClient)))).Methods("DELETE")
 }
 
-// GetArtworksHandler provides a HTTP endpoint to fetch all the Artworks.
+// GetArtworksHandler provides a HTTP endpoint to fetch a page of Artworks,
+// it accepts the pagination, sorting and filtering params described on
+// ParseListOptions.
+//
+// The total amount of matching Artworks is sent on the X-Total-Count header
+// and the next page URL on the Link header (rel="next") when there is one.
+//
+// The Artworks are streamed as they are read from the database, as a JSON
+// array or as newline delimited JSON when the Accept header holds
+// application/x-ndjson.
 //
 // Response example:
 // [{
@@ -61,18 +131,211 @@ func ConfigureHandlers(r *mux.Router, db *sql.DB) {
 // Returns a CustomHander ready to be added to a HTTP server / router.
 func GetArtworksHandler(artworksClient ArtworksController) handler.CustomHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
-		artworks, err := artworksClient.GetArtworks()
+		return listArtworks(artworksClient, w, r, false)
+	}
+}
+
+// GetTrashHandler provides a HTTP endpoint to fetch a page of the deleted
+// Artworks, it works as GetArtworksHandler does.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHander ready to be added to a HTTP server / router.
+func GetTrashHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		return listArtworks(artworksClient, w, r, true)
+	}
+}
+
+// GetPublicArtworksHandler provides a HTTP endpoint to fetch a page of the
+// publicable Artworks, holding only their public fields. It works as
+// GetArtworksHandler does, but filters and sorting are only allowed on the
+// public fields.
+//
+// Response example:
+// [{
+//   id: 1,
+//   rei: '#elle',
+//   tit: 'Vista del puerto de Mahón',
+//   ...
+// }]
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+// fields : The public Artwork fields.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetPublicArtworksHandler(artworksClient ArtworksController, fields []string) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		opts, err := ParseListOptions(r.URL.Query())
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
+		}
+		opts.Public = true
+
+		if err := checkPublicOptions(fields, opts); err != nil {
+			return &handler.HTTPError{err, http.StatusBadRequest}
+		}
+
+		return writeArtworksPage(artworksClient, w, r, opts, publicArtwork(fields))
+	}
+}
+
+// GetPublicArtworkHandler provides a HTTP endpoint to fetch a single
+// publicable Artwork, holding only its public fields. Non publicable Artworks
+// are not found, the same as the missing ones.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+// fields : The public Artwork fields.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetPublicArtworkHandler(artworksClient ArtworksController, fields []string) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		artwork, err := artworksClient.GetArtwork(r.Context(), urlID)
+		if err == nil && !artwork.Publicable {
+			err = newError(ErrNotFound, "Unable to find an Artwork with id: %d", urlID)
+		}
+
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		json.NewEncoder(w).Encode(publicArtwork(fields)(artwork))
+		return nil
+	}
+}
+
+// ExportArtworksHandler provides a HTTP endpoint to download the Artworks
+// catalogue as a file, the Artworks are streamed from the database as they
+// are written.
+//
+// Available params:
+//
+// 'format': One of csv, xlsx or jsonl, required.
+// 'sort', 'aut', 'ubi', 'tip', 'tec', 'est', 'pro': As on the listing, see
+// ParseListOptions. Pagination params are ignored, every matching Artwork is
+// exported.
+//
+// The CSV and XLSX exports have a header row with the Spanish field names,
+// see ExportLabels. The JSON Lines export has an Artwork per line, as the
+// rest of the API.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func ExportArtworksHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		name := r.URL.Query().Get("format")
+		format, ok := exportFormats[name]
+		if !ok {
+			return &handler.HTTPError{
+				errors.New("The format param should be one of csv, xlsx or jsonl"),
+				http.StatusBadRequest,
+			}
+		}
+
+		opts, err := ParseListOptions(r.URL.Query())
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
+		}
+
+		opts.Limit, opts.Offset, opts.Cursor = 0, 0, ""
+
+		if err := checkFilters(r, opts); err != nil {
+			return httpError(err, http.StatusForbidden)
+		}
+
+		return streamArtworks(artworksClient, w, r, opts, format.contentType, func(body io.Writer) (artworksWriter, error) {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"artworks.%s\"", name))
+			return format.newWriter(body)
+		})
+	}
+}
+
+// SearchArtworksHandler provides a HTTP endpoint to perform a full-text
+// search on the Artworks descriptive fields.
+//
+// Available params:
+//
+// 'q': The words to search for, required.
+// 'limit': The maximum amount of results, up to MaxListLimit.
+//
+// Response example:
+// [{
+//   artwork: { id: 1, tit: 'Vista del puerto de Mahón', ... },
+//   score: 3.2,
+//   highlights: { tit: 'Vista del puerto de <em>Mahón</em>' }
+// }]
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func SearchArtworksHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			return &handler.HTTPError{
+				errors.New("Unable to search Artworks without the q param"),
+				http.StatusBadRequest,
+			}
+		}
+
+		limit := DefaultSearchLimit
+		if param := r.URL.Query().Get("limit"); param != "" {
+			value, err := strconv.Atoi(param)
+			if err != nil || value < 1 || value > MaxListLimit {
+				return &handler.HTTPError{
+					fmt.Errorf("The limit param should be a number between 1 and %d", MaxListLimit),
+					http.StatusBadRequest,
+				}
+			}
+			limit = value
+		}
+
+		results, err := artworksClient.SearchArtworks(r.Context(), query, limit)
 		if err != nil {
-			return &handler.HTTPError{err, http.StatusInternalServerError}
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		for i := range results {
+			results[i].Artwork = *redactArtwork(r, &results[i].Artwork)
 		}
 
-		json.NewEncoder(w).Encode(artworks)
+		json.NewEncoder(w).Encode(results)
+		return nil
+	}
+}
+
+// GetSchemaHandler provides a HTTP endpoint to fetch the Artwork JSON Schema,
+// so clients could validate the Artworks before sending them.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetSchemaHandler() handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		w.Header().Set("Content-Type", SchemaContentType)
+		w.Write(ArtworkSchema)
 		return nil
 	}
 }
 
 // AddArtworkHandler provides a HTTP endpoint to insert an Artwork information.
 //
+// The request body is validated against the Artwork JSON Schema, the response
+// is 422 Unprocessable Entity with the failures by field otherwise. When the
+// Artwork rei is already used the response is 409 Conflict, holding the
+// conflicting Artwork id as artwork_id.
+//
 // artworksClient : The Artworks client either real or fake that implements the
 //		  						 ArtworksController interface, a fake artworks client is used
 //      						 for testing purposes.
@@ -80,28 +343,88 @@ func GetArtworksHandler(artworksClient ArtworksController) handler.CustomHandler
 // Returns a CustomHandler ready to be added to a HTTP server / router.
 func AddArtworkHandler(artworksClient ArtworksController) handler.CustomHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
-		var artwork Artwork
-
-		if err := json.NewDecoder(r.Body).Decode(&artwork); err != nil {
-			return &handler.HTTPError{err, http.StatusBadRequest}
+		artwork, err := decodeArtwork(r)
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
 		}
-		defer r.Body.Close()
 
 		artwork.CreatedAt = time.Now().Unix()
 
-		if err := artworksClient.AddUpdateArtwork("INSERT", &artwork); err != nil {
-			return &handler.HTTPError{err, http.StatusInternalServerError}
+		if err := artworksClient.AddUpdateArtwork(r.Context(), "INSERT", artwork, requestAuthor(r)); err != nil {
+			return httpError(err, http.StatusInternalServerError)
 		}
 
+		w.Header().Set("ETag", artworkETag(artwork))
 		w.WriteHeader(http.StatusCreated)
 
-		json.NewEncoder(w).Encode(artwork)
+		json.NewEncoder(w).Encode(redactArtwork(r, artwork))
+		return nil
+	}
+}
+
+// ImportArtworksHandler provides a HTTP endpoint to insert Artworks in bulk
+// from a CSV inventory, the first CSV row should be the header.
+//
+// The headers should be either Artwork field names or mapped to them using
+// the map param, see ParseImportOptions. All the rows are validated before
+// anything is stored, the response is 422 Unprocessable Entity with the
+// failures by row and field, or 409 Conflict when any rei is already in use.
+// Either all the Artworks are inserted or none.
+//
+// On a dry run (dry_run=true) the response is 200 OK once the import is
+// checked, nothing is stored. It's 201 Created with the new Artworks ids
+// otherwise.
+//
+// Response example:
+// {
+//   dry_run: false,
+//   total: 2,
+//   ids: [3, 4]
+// }
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func ImportArtworksHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		opts, err := ParseImportOptions(r.URL.Query())
+		if err != nil {
+			return &handler.HTTPError{err, http.StatusBadRequest}
+		}
+
+		records, err := ParseImport(http.MaxBytesReader(w, r.Body, MaxImportSize), opts)
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
+		}
+
+		if err := artworksClient.ImportArtworks(r.Context(), records, requestAuthor(r), opts.DryRun); err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		result := ImportResult{DryRun: opts.DryRun, Total: len(records)}
+		status := http.StatusOK
+		if !opts.DryRun {
+			status = http.StatusCreated
+			result.IDs = make([]int, len(records))
+			for i, record := range records {
+				result.IDs[i] = record.Artwork.ID
+			}
+		}
+
+		w.WriteHeader(status)
+
+		json.NewEncoder(w).Encode(result)
 		return nil
 	}
 }
 
 // GetArtworkHandler provides a HTTP endpoint to fetch a single Artwork.
 //
+// The Artwork version is sent on the ETag header, a request with a matching
+// If-None-Match header gets a 304 Not Modified response.
+//
 // Response example:
 // {
 //   ID: 1,
@@ -128,12 +451,46 @@ func GetArtworkHandler(artworksClient ArtworksController) handler.CustomHandler
 			}
 		}
 
-		artwork, err := artworksClient.GetArtwork(urlID)
+		artwork, err := artworksClient.GetArtwork(r.Context(), urlID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		etag := artworkETag(artwork)
+		w.Header().Set("ETag", etag)
+
+		if noneMatch(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+
+		json.NewEncoder(w).Encode(redactArtwork(r, artwork))
+		return nil
+	}
+}
+
+// GetArtworkByReiHandler provides a HTTP endpoint to fetch a single Artwork by
+// its rei, the museum registration number. As the rei usually starts with a
+// '#' it should be URL encoded, as in /artworks/by-rei/%23EU82REE.
+//
+// The Artwork version is sent on the ETag header, the same as on
+// GetArtworkHandler.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHander ready to be added to a HTTP server / router.
+func GetArtworkByReiHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		artwork, err := artworksClient.GetArtworkByRei(r.Context(), mux.Vars(r)["rei"])
 		if err != nil {
-			return &handler.HTTPError{err, http.StatusInternalServerError}
+			return httpError(err, http.StatusInternalServerError)
 		}
 
-		json.NewEncoder(w).Encode(artwork)
+		w.Header().Set("ETag", artworkETag(artwork))
+
+		json.NewEncoder(w).Encode(redactArtwork(r, artwork))
 		return nil
 	}
 }
@@ -141,6 +498,12 @@ func GetArtworkHandler(artworksClient ArtworksController) handler.CustomHandler
 // UpdateArtworkHandler provides a HTTP endpoint to update an Artwork
 // information.
 //
+// The request body is validated against the Artwork JSON Schema, the response
+// is 422 Unprocessable Entity with the failures by field otherwise.
+//
+// When the If-Match header is sent it should match the Artwork ETag, the
+// response is 412 Precondition Failed otherwise.
+//
 // artworksClient : The Artworks client either real or fake that implements the
 //		  						 ArtworksController interface, a fake artworks client is used
 //      						 for testing purposes.
@@ -148,12 +511,10 @@ func GetArtworkHandler(artworksClient ArtworksController) handler.CustomHandler
 // Returns a CustomHandler ready to be added to a HTTP server / router.
 func UpdateArtworkHandler(artworksClient ArtworksController) handler.CustomHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
-		var artwork Artwork
-
-		if err := json.NewDecoder(r.Body).Decode(&artwork); err != nil {
-			return &handler.HTTPError{err, http.StatusBadRequest}
+		artwork, err := decodeArtwork(r)
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
 		}
-		defer r.Body.Close()
 
 		if urlID, _ := strconv.Atoi(mux.Vars(r)["id"]); urlID != artwork.ID {
 			return &handler.HTTPError{
@@ -162,17 +523,98 @@ func UpdateArtworkHandler(artworksClient ArtworksController) handler.CustomHandl
 			}
 		}
 
-		if err := artworksClient.AddUpdateArtwork("UPDATE", &artwork); err != nil {
-			return &handler.HTTPError{err, http.StatusInternalServerError}
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			return &handler.HTTPError{err, http.StatusPreconditionFailed}
+		}
+		artwork.Version = version
+
+		if err := artworksClient.AddUpdateArtwork(r.Context(), "UPDATE", artwork, requestAuthor(r)); err != nil {
+			return httpError(err, http.StatusInternalServerError)
 		}
 
+		w.Header().Set("ETag", artworkETag(artwork))
 		w.WriteHeader(http.StatusNoContent)
 		return nil
 	}
 }
 
+// PatchArtworkHandler provides a HTTP endpoint to update only some fields of
+// an Artwork, it responds with the patched Artwork.
+//
+// Supported request Content-Types:
+//
+// 'application/merge-patch+json': RFC 7396 JSON Merge Patch, the default.
+// 'application/json-patch+json': RFC 6902 JSON Patch.
+//
+// Request example (merge patch):
+// {
+//   est: 'Regular',
+//   ubi: null
+// }
+//
+// When the If-Match header is sent it should match the Artwork ETag, the
+// response is 412 Precondition Failed otherwise.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func PatchArtworkHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
+		}
+		defer r.Body.Close()
+
+		var patch *Artwork
+		var fields []string
+
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch contentType {
+		case MergePatchContentType, "application/json", "":
+			patch, fields, err = ParseMergePatch(data)
+		case JSONPatchContentType:
+			var current *Artwork
+			if current, err = artworksClient.GetArtwork(r.Context(), urlID); err != nil {
+				return httpError(err, http.StatusInternalServerError)
+			}
+			patch, fields, err = ParseJSONPatch(data, current)
+		default:
+			return &handler.HTTPError{
+				fmt.Errorf("Unable to patch an Artwork with the %s Content-Type", contentType),
+				http.StatusUnsupportedMediaType,
+			}
+		}
+
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
+		}
+
+		if patch.Version, err = ifMatchVersion(r); err != nil {
+			return &handler.HTTPError{err, http.StatusPreconditionFailed}
+		}
+
+		artwork, err := artworksClient.PatchArtwork(r.Context(), urlID, patch, fields, requestAuthor(r))
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		w.Header().Set("ETag", artworkETag(artwork))
+		json.NewEncoder(w).Encode(redactArtwork(r, artwork))
+		return nil
+	}
+}
+
 // DeleteArtworkHandler provides a HTTP endpoint to delete Artwork information
-// by the given ID.
+// by the given ID, the Artwork is moved to the trash.
+//
+// When the If-Match header is sent it should match the Artwork ETag, the
+// response is 412 Precondition Failed otherwise.
 //
 // artworksClient : The Artworks client either real or fake that implements the
 //		  						 ArtworksController interface, a fake artworks client is used
@@ -183,8 +625,675 @@ func DeleteArtworkHandler(artworksClient ArtworksController) handler.CustomHandl
 	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
 		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
 
-		if err := artworksClient.DeleteArtwork(urlID); err != nil {
-			return &handler.HTTPError{err, http.StatusInternalServerError}
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			return &handler.HTTPError{err, http.StatusPreconditionFailed}
+		}
+
+		if err := artworksClient.DeleteArtwork(r.Context(), urlID, version, requestAuthor(r)); err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// nextPageURL returns the URL of the page following the given one, it keeps
+// the requested params and moves either the cursor or the offset forward.
+//
+// current: The requested URL.
+// opts: The requested ListOptions.
+// page: The returned ArtworksPage.
+//
+// Returns the next page URL, empty if the given page is the last one.
+func nextPageURL(current *url.URL, opts *ListOptions, page *ArtworksPage) string {
+	query := current.Query()
+
+	if opts.Offset > 0 {
+		next := opts.Offset + opts.Limit
+		if next >= page.Total {
+			return ""
+		}
+		query.Set("offset", strconv.Itoa(next))
+	} else {
+		if page.NextCursor == "" {
+			return ""
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+
+	next := url.URL{Path: current.Path, RawQuery: query.Encode()}
+	return next.String()
+}
+
+// GetRevisionsHandler provides a HTTP endpoint to fetch the history of an
+// Artwork, every Revision with its field-level changes.
+//
+// Response example:
+// [{
+//   rev: 1,
+//   artwork_id: 1,
+//   action: 'INSERT',
+//   author: 'jcleira',
+//   created_at: 1489140631,
+//   changes: { rei: { from: '', to: '#elle' }, ... }
+// }]
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetRevisionsHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		revisions, err := artworksClient.GetRevisions(r.Context(), urlID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		for i := range revisions {
+			revisions[i] = *redactRevision(r, &revisions[i])
+		}
+
+		json.NewEncoder(w).Encode(revisions)
+		return nil
+	}
+}
+
+// GetRevisionHandler provides a HTTP endpoint to fetch a single Revision of
+// an Artwork, including the Artwork state at that Revision.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetRevisionHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+		urlRev, _ := strconv.Atoi(mux.Vars(r)["rev"])
+
+		revision, err := artworksClient.GetRevision(r.Context(), urlID, urlRev)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		json.NewEncoder(w).Encode(redactRevision(r, revision))
+		return nil
+	}
+}
+
+// RestoreRevisionHandler provides a HTTP endpoint to bring an Artwork back to
+// the state it had on a previous Revision, it responds with the restored
+// Artwork.
+//
+// When the If-Match header is sent it should match the Artwork ETag, the
+// response is 412 Precondition Failed otherwise.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func RestoreRevisionHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+		urlRev, _ := strconv.Atoi(mux.Vars(r)["rev"])
+
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			return &handler.HTTPError{err, http.StatusPreconditionFailed}
+		}
+
+		artwork, err := artworksClient.RestoreRevision(r.Context(), urlID, urlRev, version, requestAuthor(r))
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		w.Header().Set("ETag", artworkETag(artwork))
+		json.NewEncoder(w).Encode(redactArtwork(r, artwork))
+		return nil
+	}
+}
+
+// decodeArtwork decodes the request body Artwork, validating it against the
+// Artwork JSON Schema.
+//
+// Returns:
+// The decoded Artwork.
+// An error if the body can't be read, an ErrValidation one if the Artwork is
+// not valid.
+func decodeArtwork(r *http.Request) (*Artwork, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if err := ValidateArtworkJSON(data); err != nil {
+		return nil, err
+	}
+
+	var artwork Artwork
+	if err := json.Unmarshal(data, &artwork); err != nil {
+		return nil, err
+	}
+
+	return &artwork, nil
+}
+
+// requestAuthor returns who performs the given request, it's recorded on the
+// Artworks history. It's always the authenticated Principal, the JWT token
+// subject or the API key name, so the history can't be forged by the client.
+//
+// Returns the author name, 'anonymous' if the request is not authenticated.
+func requestAuthor(r *http.Request) string {
+	if principal := PrincipalFromContext(r.Context()); principal != nil {
+		return principal.Subject
+	}
+
+	return "anonymous"
+}
+
+// RestoreArtworkHandler provides a HTTP endpoint to recover a deleted Artwork
+// from the trash, it responds with the restored Artwork.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func RestoreArtworkHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		artwork, err := artworksClient.RestoreArtwork(r.Context(), urlID, requestAuthor(r))
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		json.NewEncoder(w).Encode(redactArtwork(r, artwork))
+		return nil
+	}
+}
+
+// PurgeArtworkHandler provides a HTTP endpoint to permanently delete an
+// Artwork on the trash, it's an admin only endpoint.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func PurgeArtworkHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		if err := artworksClient.PurgeArtwork(r.Context(), urlID, requestAuthor(r)); err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// AddImageHandler provides a HTTP endpoint to upload an image of an Artwork,
+// as the 'image' part of a multipart/form-data request. It responds with the
+// stored Image and its URL on the Location header.
+//
+// The image type is sniffed from its content, only JPEG, PNG, GIF and WebP
+// images are accepted, the response is 415 Unsupported Media Type otherwise.
+// Images larger than MaxImageSize get a 413 Request Entity Too Large, the ones
+// exceeding the client MaxImagePixels or whose dimensions can't be read get a
+// 422 Unprocessable Entity.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func AddImageHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		r.Body = http.MaxBytesReader(w, r.Body, MaxImageSize+multipartOverhead)
+
+		reader, err := r.MultipartReader()
+		if err != nil {
+			return httpError(err, http.StatusBadRequest)
+		}
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return &handler.HTTPError{
+					errors.New("The request should have an image part"),
+					http.StatusBadRequest,
+				}
+			}
+			if err != nil {
+				return httpError(err, http.StatusBadRequest)
+			}
+
+			if part.FormName() != "image" {
+				continue
+			}
+
+			content := bufio.NewReaderSize(part, sniffLen)
+			head, err := content.Peek(sniffLen)
+			if err != nil && err != io.EOF {
+				return httpError(err, http.StatusBadRequest)
+			}
+
+			contentType := http.DetectContentType(head)
+			if !imageContentTypes[contentType] {
+				return &handler.HTTPError{
+					fmt.Errorf("The image type %s is not supported", contentType),
+					http.StatusUnsupportedMediaType,
+				}
+			}
+
+			image := &Image{
+				ArtworkID:   urlID,
+				Filename:    imageFilename(part.FileName()),
+				ContentType: contentType,
+			}
+
+			if err := artworksClient.AddImage(r.Context(), image, content); err != nil {
+				return httpError(err, http.StatusInternalServerError)
+			}
+
+			w.Header().Set("Location", fmt.Sprintf("/artworks/%d/images/%d", image.ArtworkID, image.ID))
+			w.WriteHeader(http.StatusCreated)
+
+			json.NewEncoder(w).Encode(image)
+			return nil
+		}
+	}
+}
+
+// GetImagesHandler provides a HTTP endpoint to fetch the images metadata of
+// an Artwork.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetImagesHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		images, err := artworksClient.GetImages(r.Context(), urlID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		json.NewEncoder(w).Encode(images)
+		return nil
+	}
+}
+
+// GetImageHandler provides a HTTP endpoint to download an image of an
+// Artwork, the original one unless a derivative is requested.
+//
+// Query params:
+//
+// size: One of the DerivativeSizes, as thumb, medium or large.
+// format: The derivative format, either jpeg (the default) or webp.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetImageHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+		imageID, _ := strconv.Atoi(mux.Vars(r)["image"])
+
+		image, err := artworksClient.GetImage(r.Context(), urlID, imageID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		size := r.URL.Query().Get("size")
+		if size == "" {
+			content, err := artworksClient.OpenImage(r.Context(), image)
+			if err != nil {
+				return httpError(err, http.StatusInternalServerError)
+			}
+			defer content.Close()
+
+			w.Header().Set("Content-Type", image.ContentType)
+			w.Header().Set("Content-Length", strconv.FormatInt(image.Size, 10))
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": image.Filename}))
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+
+			io.Copy(w, content)
+			return nil
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "jpeg"
+		}
+
+		content, err := artworksClient.OpenDerivative(r.Context(), image, size, format)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+		defer content.Close()
+
+		filename := strings.TrimSuffix(image.Filename, filepath.Ext(image.Filename)) + "-" + size + "." + format
+
+		w.Header().Set("Content-Type", derivativeFormats[format].contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		io.Copy(w, content)
+		return nil
+	}
+}
+
+// DeleteImageHandler provides a HTTP endpoint to delete an image of an
+// Artwork.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func DeleteImageHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+		imageID, _ := strconv.Atoi(mux.Vars(r)["image"])
+
+		if err := artworksClient.DeleteImage(r.Context(), urlID, imageID); err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// GetIIIFManifestHandler provides a HTTP endpoint to fetch the IIIF
+// Presentation API 3.0 manifest of an Artwork, every Artwork image is a
+// Canvas served by the IIIF Image API endpoints.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetIIIFManifestHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		artwork, err := artworksClient.GetArtwork(r.Context(), urlID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		images, err := artworksClient.GetImages(r.Context(), urlID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		sizes := make([]image.Point, len(images))
+		for i := range images {
+			if sizes[i], err = iiifSize(r.Context(), artworksClient, &images[i]); err != nil {
+				return httpError(err, http.StatusInternalServerError)
+			}
+		}
+
+		w.Header().Set("Content-Type", IIIFManifestContentType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		json.NewEncoder(w).Encode(NewIIIFManifest(artwork, images, sizes, requestBaseURL(r)))
+		return nil
+	}
+}
+
+// GetIIIFServiceHandler provides the IIIF Image API base URI of an Artwork
+// image, it redirects to the image information.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetIIIFServiceHandler() handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		http.Redirect(w, r, r.URL.Path+"/info.json", http.StatusSeeOther)
+		return nil
+	}
+}
+
+// GetIIIFInfoHandler provides a HTTP endpoint to fetch the IIIF Image API 3.0
+// information (info.json) of an Artwork image.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetIIIFInfoHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+		imageID, _ := strconv.Atoi(mux.Vars(r)["image"])
+
+		img, err := artworksClient.GetImage(r.Context(), urlID, imageID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		size, err := iiifSize(r.Context(), artworksClient, img)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		id := fmt.Sprintf("%s/iiif/artworks/%d/images/%d", requestBaseURL(r), urlID, imageID)
+
+		w.Header().Set("Content-Type", IIIFContentType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		json.NewEncoder(w).Encode(NewIIIFInfo(id, size.X, size.Y))
+		return nil
+	}
+}
+
+// GetIIIFImageHandler provides the IIIF Image API 3.0 image requests of an
+// Artwork image: {region}/{size}/{rotation}/{quality}.{format}, as described
+// on ParseIIIFImageRequest. Not valid requests get a 400 Bad Request, as
+// required by the IIIF Image API.
+//
+// artworksClient : The Artworks client either real or fake that implements the
+//		  						 ArtworksController interface, a fake artworks client is used
+//      						 for testing purposes.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func GetIIIFImageHandler(artworksClient ArtworksController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		vars := mux.Vars(r)
+		urlID, _ := strconv.Atoi(vars["id"])
+		imageID, _ := strconv.Atoi(vars["image"])
+
+		img, err := artworksClient.GetImage(r.Context(), urlID, imageID)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		// The request is validated before decoding the image when its size is
+		// already known, so oversized requests don't decode anything.
+		var decoded image.Image
+		size, cached := iiifSizes.Load(img.StorageKey)
+		if !cached {
+			if decoded, err = decodeImage(r.Context(), artworksClient, img); err != nil {
+				return httpError(err, http.StatusInternalServerError)
+			}
+			size = decoded.Bounds().Size()
+		}
+
+		req, err := ParseIIIFImageRequest(vars["region"], vars["size"], vars["rotation"],
+			vars["quality"], vars["format"], size.(image.Point).X, size.(image.Point).Y)
+		if err != nil {
+			return &handler.HTTPError{err, http.StatusBadRequest}
+		}
+
+		if decoded == nil {
+			if decoded, err = decodeImage(r.Context(), artworksClient, img); err != nil {
+				return httpError(err, http.StatusInternalServerError)
+			}
+		}
+
+		w.Header().Set("Content-Type", req.ContentType())
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Link", fmt.Sprintf(`<%s>;rel="profile"`, "http://iiif.io/api/image/3/level2.json"))
+
+		if err := req.Encode(w, req.Apply(decoded)); err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		return nil
+	}
+}
+
+// listArtworks streams a page of Artworks, either the live or the deleted
+// ones, using the pagination, sorting and filtering params of the request.
+// The page is a JSON array unless the request accepts NDJSONContentType.
+//
+// Returns an HTTPError if any.
+func listArtworks(artworksClient ArtworksController, w http.ResponseWriter, r *http.Request, deleted bool) *handler.HTTPError {
+	opts, err := ParseListOptions(r.URL.Query())
+	if err != nil {
+		return httpError(err, http.StatusBadRequest)
+	}
+	opts.Deleted = deleted
+
+	if err := checkFilters(r, opts); err != nil {
+		return httpError(err, http.StatusForbidden)
+	}
+
+	return writeArtworksPage(artworksClient, w, r, opts, nil)
+}
+
+// writeArtworksPage streams the page of Artworks matching the given
+// ListOptions, the total amount of matching Artworks is sent on the
+// X-Total-Count header and the next page URL on the Link header.
+//
+// p: The projection the Artworks are encoded as, nil for the Artworks
+// themselves.
+//
+// Returns an HTTPError if any.
+func writeArtworksPage(artworksClient ArtworksController, w http.ResponseWriter, r *http.Request, opts *ListOptions,
+	p projection) *handler.HTTPError {
+	page, err := artworksClient.QueryArtworksPage(r.Context(), opts)
+	if err != nil {
+		return httpError(err, http.StatusInternalServerError)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if next := nextPageURL(r.URL, opts, page); next != "" {
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next))
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), NDJSONContentType) {
+		return streamArtworks(artworksClient, w, r, opts, NDJSONContentType, func(body io.Writer) (artworksWriter, error) {
+			return newProjectedNDJSONWriter(body, p)
+		})
+	}
+
+	return streamArtworks(artworksClient, w, r, opts, "application/json", func(body io.Writer) (artworksWriter, error) {
+		return newProjectedJSONArrayWriter(body, p)
+	})
+}
+
+// artworkETag returns the ETag header value of the given Artwork, based on
+// its version.
+func artworkETag(artwork *Artwork) string {
+	return fmt.Sprintf("\"%d\"", artwork.Version)
+}
+
+// ifMatchVersion returns the Artwork version expected by the request If-Match
+// header.
+//
+// Returns:
+// The expected version, zero if the header is missing or '*'.
+// An error if the header doesn't hold a valid Artwork ETag.
+func ifMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(header, "\""))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("The If-Match header %s is not a valid Artwork ETag", header)
+	}
+
+	return version, nil
+}
+
+// noneMatch tells whether the given If-None-Match header value matches the
+// given ETag, weak comparison is used as RFC 7232 requires.
+func noneMatch(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreateAPIKeyHandler provides a HTTP endpoint to create a static API key,
+// it's an admin only endpoint. The request body holds the key name and role
+// as {"name": "data-entry", "role": "editor"}, the role defaults to viewer.
+// The response holds the key itself, it's the only time it's shown as only
+// its hash is stored.
+//
+// apiKeysClient: The client that implements the APIKeysController interface.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func CreateAPIKeyHandler(apiKeysClient APIKeysController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		var body struct {
+			Name string `json:"name"`
+			Role Role   `json:"role"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return &handler.HTTPError{fmt.Errorf("Unable to decode the API key. Err: %s", err), http.StatusBadRequest}
+		}
+
+		apiKey, err := apiKeysClient.CreateAPIKey(r.Context(), body.Name, body.Role)
+		if err != nil {
+			return httpError(err, http.StatusInternalServerError)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(apiKey)
+		return nil
+	}
+}
+
+// RevokeAPIKeyHandler provides a HTTP endpoint to revoke a static API key,
+// it's an admin only endpoint.
+//
+// apiKeysClient: The client that implements the APIKeysController interface.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func RevokeAPIKeyHandler(apiKeysClient APIKeysController) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		urlID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		if err := apiKeysClient.RevokeAPIKey(r.Context(), urlID); err != nil {
+			return httpError(err, http.StatusInternalServerError)
 		}
 
 		w.WriteHeader(http.StatusNoContent)
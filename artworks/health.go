@@ -0,0 +1,139 @@
+package artworks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jcleira/handler/handler"
+)
+
+// HealthCheck tells whether a component the API depends on is working, as
+// the database.
+type HealthCheck func(ctx context.Context) error
+
+// Health holds the components checked to tell whether the API is ready to
+// serve requests.
+type Health struct {
+	Checks map[string]HealthCheck
+
+	// Timeout bounds every check, a stalled component is not ready.
+	Timeout time.Duration
+}
+
+// ComponentStatus is a component check result.
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthStatus is the health endpoints response.
+type HealthStatus struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// ConfigureHealthHandlers is meant to be called by the server.go main
+// routine to register the Kubernetes probes endpoints, they don't require
+// authentication.
+//
+// r: The HTTP server *mux.Router to be configured.
+// health: The components to check for readiness.
+//
+// Returns nothing.
+func ConfigureHealthHandlers(r *mux.Router, health *Health) {
+	r.Handle("/healthz", ProblemHandler(HealthzHandler())).Methods("GET")
+	r.Handle("/readyz", ProblemHandler(ReadyzHandler(health))).Methods("GET")
+}
+
+// HealthzHandler provides a HTTP endpoint telling the process is alive, it
+// doesn't check any component so a database outage doesn't restart the pods.
+//
+// Response example:
+// {"status": "ok"}
+//
+// Returns a handler.CustomHandler to be used on the router.
+func HealthzHandler() handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HealthStatus{Status: "ok"})
+
+		return nil
+	}
+}
+
+// ReadyzHandler provides a HTTP endpoint telling whether the API is ready to
+// serve requests, every Health check should pass. It responds with a 503
+// Service Unavailable status otherwise.
+//
+// Response example:
+// {
+//   "status": "unavailable",
+//   "components": {
+//     "database": {"status": "ok"},
+//     "migrations": {"status": "fail", "error": "The ... migration is not applied"}
+//   }
+// }
+//
+// health: The components to check.
+//
+// Returns a handler.CustomHandler to be used on the router.
+func ReadyzHandler(health *Health) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		status := health.check(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if status.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+
+		return nil
+	}
+}
+
+// check runs every Health check, in name order.
+func (h *Health) check(ctx context.Context) HealthStatus {
+	status := HealthStatus{
+		Status:     "ok",
+		Components: make(map[string]ComponentStatus, len(h.Checks)),
+	}
+
+	names := make([]string, 0, len(h.Checks))
+	for name := range h.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, h.Timeout)
+		err := h.Checks[name](checkCtx)
+		cancel()
+
+		if err != nil {
+			status.Status = "unavailable"
+			status.Components[name] = ComponentStatus{Status: "fail", Error: err.Error()}
+			continue
+		}
+
+		status.Components[name] = ComponentStatus{Status: "ok"}
+	}
+
+	return status
+}
+
+// Ping checks the database connection is alive.
+//
+// ctx: The request context.
+//
+// Returns an error if the database can't be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx, "Ping")
+	defer cancel()
+
+	return c.DB.PingContext(ctx)
+}
@@ -0,0 +1,83 @@
+package artworks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("The 2-b.sql migration is not applied") }
+	stalled := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		url        string
+		checks     map[string]HealthCheck
+		statusCode int
+		components map[string]string
+	}{
+		{
+			url:        "/healthz",
+			checks:     map[string]HealthCheck{"database": failing},
+			statusCode: http.StatusOK,
+		},
+		{
+			url:        "/readyz",
+			checks:     map[string]HealthCheck{"database": ok, "migrations": ok},
+			statusCode: http.StatusOK,
+			components: map[string]string{"database": "ok", "migrations": "ok"},
+		},
+		{
+			url:        "/readyz",
+			checks:     map[string]HealthCheck{"database": ok, "migrations": failing},
+			statusCode: http.StatusServiceUnavailable,
+			components: map[string]string{"database": "ok", "migrations": "fail"},
+		},
+		{
+			url:        "/readyz",
+			checks:     map[string]HealthCheck{"database": stalled},
+			statusCode: http.StatusServiceUnavailable,
+			components: map[string]string{"database": "fail"},
+		},
+	}
+
+	for _, test := range tests {
+		r := mux.NewRouter()
+		ConfigureHealthHandlers(r, &Health{Checks: test.checks, Timeout: 10 * time.Millisecond})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+
+		if w.Code != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.url, w.Code, test.statusCode)
+			continue
+		}
+
+		var status HealthStatus
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Errorf("Unable to decode the %s response. Err: %s", test.url, err)
+			continue
+		}
+
+		if len(status.Components) != len(test.components) {
+			t.Errorf("The components don't match for %s Got: %v Expected: %v", test.url, status.Components, test.components)
+			continue
+		}
+
+		for name, expected := range test.components {
+			if status.Components[name].Status != expected {
+				t.Errorf("The %s component status don't match for %s Got: %v Expected: %s", name, test.url, status.Components[name], expected)
+			}
+		}
+	}
+}
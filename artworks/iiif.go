@@ -0,0 +1,510 @@
+package artworks
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// IIIFImageContext is the IIIF Image API 3.0 JSON-LD context.
+	IIIFImageContext = "http://iiif.io/api/image/3/context.json"
+
+	// IIIFPresentationContext is the IIIF Presentation API 3.0 JSON-LD
+	// context.
+	IIIFPresentationContext = "http://iiif.io/api/presentation/3/context.json"
+
+	// IIIFContentType is the IIIF JSON-LD responses media type.
+	IIIFContentType = `application/ld+json;profile="http://iiif.io/api/image/3/context.json"`
+
+	// IIIFManifestContentType is the IIIF Presentation manifests media type.
+	IIIFManifestContentType = `application/ld+json;profile="http://iiif.io/api/presentation/3/context.json"`
+)
+
+// IIIFMaxWidth, IIIFMaxHeight and IIIFMaxArea bound the IIIF Image API
+// responses size, upscaled ones included, larger sizes are rejected and the
+// max size is scaled down to fit them. They are advertised on info.json.
+var (
+	IIIFMaxWidth  = 10000
+	IIIFMaxHeight = 10000
+	IIIFMaxArea   = 25000000
+)
+
+// iiifFormats are the IIIF Image API formats by extension.
+var iiifFormats = map[string]struct {
+	contentType string
+	format      imaging.Format
+}{
+	"jpg": {"image/jpeg", imaging.JPEG},
+	"png": {"image/png", imaging.PNG},
+	"gif": {"image/gif", imaging.GIF},
+}
+
+// iiifMetadata are the Artwork fields shown on the IIIF manifests metadata,
+// with their Spanish labels.
+var iiifMetadata = []struct {
+	label string
+	field func(*Artwork) string
+}{
+	{"Título", func(a *Artwork) string { return a.Tit }},
+	{"Autor", func(a *Artwork) string { return a.Aut }},
+	{"Fecha", func(a *Artwork) string { return a.Fec }},
+	{"Dimensiones", func(a *Artwork) string { return a.Dim }},
+	{"Descripción", func(a *Artwork) string { return a.Des }},
+}
+
+// iiifSizes caches the images width and height once decoded, the images
+// content never changes as every upload gets a new Storage key.
+var iiifSizes sync.Map
+
+// IIIFImageRequest is a parsed IIIF Image API request:
+// {region}/{size}/{rotation}/{quality}.{format}
+type IIIFImageRequest struct {
+	Region   image.Rectangle
+	Width    int
+	Height   int
+	Rotation float64
+	Mirror   bool
+	Quality  string
+	Format   string
+}
+
+// IIIFInfo is the IIIF Image API 3.0 image information (info.json).
+type IIIFInfo struct {
+	Context        string   `json:"@context"`
+	ID             string   `json:"id"`
+	Type           string   `json:"type"`
+	Protocol       string   `json:"protocol"`
+	Profile        string   `json:"profile"`
+	Width          int      `json:"width"`
+	Height         int      `json:"height"`
+	ExtraQualities []string `json:"extraQualities"`
+	ExtraFormats   []string `json:"extraFormats"`
+	ExtraFeatures  []string `json:"extraFeatures"`
+	MaxWidth       int      `json:"maxWidth"`
+	MaxHeight      int      `json:"maxHeight"`
+	MaxArea        int      `json:"maxArea"`
+}
+
+// IIIFManifest is a IIIF Presentation API 3.0 manifest, every Artwork image
+// is a Canvas.
+type IIIFManifest struct {
+	Context  string          `json:"@context"`
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Label    IIIFLanguageMap `json:"label"`
+	Summary  IIIFLanguageMap `json:"summary,omitempty"`
+	Metadata []IIIFMetadata  `json:"metadata"`
+	Items    []IIIFResource  `json:"items"`
+}
+
+// IIIFLanguageMap is a IIIF Presentation API language map.
+type IIIFLanguageMap map[string][]string
+
+// IIIFMetadata is a IIIF Presentation API metadata entry.
+type IIIFMetadata struct {
+	Label IIIFLanguageMap `json:"label"`
+	Value IIIFLanguageMap `json:"value"`
+}
+
+// IIIFResource is any IIIF Presentation API resource nested on a manifest:
+// Canvas, AnnotationPage, Annotation, Image or ImageService3.
+type IIIFResource struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Profile    string         `json:"profile,omitempty"`
+	Format     string         `json:"format,omitempty"`
+	Motivation string         `json:"motivation,omitempty"`
+	Width      int            `json:"width,omitempty"`
+	Height     int            `json:"height,omitempty"`
+	Target     string         `json:"target,omitempty"`
+	Body       *IIIFResource  `json:"body,omitempty"`
+	Service    []IIIFResource `json:"service,omitempty"`
+	Items      []IIIFResource `json:"items,omitempty"`
+}
+
+// NewIIIFInfo returns the IIIF image information of an image.
+//
+// id: The IIIF image service URI.
+// width: The image width.
+// height: The image height.
+//
+// Returns an IIIFInfo.
+func NewIIIFInfo(id string, width, height int) *IIIFInfo {
+	return &IIIFInfo{
+		Context:        IIIFImageContext,
+		ID:             id,
+		Type:           "ImageService3",
+		Protocol:       "http://iiif.io/api/image",
+		Profile:        "level2",
+		Width:          width,
+		Height:         height,
+		ExtraQualities: []string{"color", "gray", "bitonal"},
+		ExtraFormats:   []string{"gif"},
+		ExtraFeatures:  []string{"mirroring", "rotationArbitrary", "sizeUpscaling"},
+		MaxWidth:       IIIFMaxWidth,
+		MaxHeight:      IIIFMaxHeight,
+		MaxArea:        IIIFMaxArea,
+	}
+}
+
+// NewIIIFManifest returns the IIIF Presentation manifest of an Artwork.
+//
+// artwork: The Artwork.
+// images: The Artwork images.
+// sizes: The images width and height, in the same order.
+// baseURL: The API base URL, as in https://artworks.example.com.
+//
+// Returns an IIIFManifest.
+func NewIIIFManifest(artwork *Artwork, images []Image, sizes []image.Point, baseURL string) *IIIFManifest {
+	artworkURL := fmt.Sprintf("%s/iiif/artworks/%d", baseURL, artwork.ID)
+
+	manifest := &IIIFManifest{
+		Context:  IIIFPresentationContext,
+		ID:       artworkURL + "/manifest.json",
+		Type:     "Manifest",
+		Label:    IIIFLanguageMap{"es": {artwork.Tit}},
+		Metadata: []IIIFMetadata{},
+		Items:    []IIIFResource{},
+	}
+
+	if artwork.Tit == "" {
+		manifest.Label = IIIFLanguageMap{"none": {artwork.Rei}}
+	}
+
+	if artwork.Des != "" {
+		manifest.Summary = IIIFLanguageMap{"es": {artwork.Des}}
+	}
+
+	for _, metadata := range iiifMetadata {
+		if value := metadata.field(artwork); value != "" {
+			manifest.Metadata = append(manifest.Metadata, IIIFMetadata{
+				Label: IIIFLanguageMap{"es": {metadata.label}},
+				Value: IIIFLanguageMap{"es": {value}},
+			})
+		}
+	}
+
+	for i, img := range images {
+		canvasURL := fmt.Sprintf("%s/canvas/%d", artworkURL, img.ID)
+		serviceURL := fmt.Sprintf("%s/images/%d", artworkURL, img.ID)
+
+		manifest.Items = append(manifest.Items, IIIFResource{
+			ID:     canvasURL,
+			Type:   "Canvas",
+			Width:  sizes[i].X,
+			Height: sizes[i].Y,
+			Items: []IIIFResource{{
+				ID:   canvasURL + "/page",
+				Type: "AnnotationPage",
+				Items: []IIIFResource{{
+					ID:         canvasURL + "/annotation",
+					Type:       "Annotation",
+					Motivation: "painting",
+					Target:     canvasURL,
+					Body: &IIIFResource{
+						ID:      serviceURL + "/full/max/0/default.jpg",
+						Type:    "Image",
+						Format:  "image/jpeg",
+						Width:   sizes[i].X,
+						Height:  sizes[i].Y,
+						Service: []IIIFResource{{ID: serviceURL, Type: "ImageService3", Profile: "level2"}},
+					},
+				}},
+			}},
+		})
+	}
+
+	return manifest
+}
+
+// ParseIIIFImageRequest parses the IIIF Image API request params for an
+// image of the given width and height.
+//
+// region: full, square, x,y,w,h or pct:x,y,w,h.
+// size: max, w,, ,h, pct:n, w,h or !w,h, any of them prefixed by ^ to allow
+// upscaling, bounded by IIIFMaxWidth, IIIFMaxHeight and IIIFMaxArea.
+// rotation: The clockwise degrees, prefixed by ! to mirror the image first.
+// quality: default, color, gray or bitonal.
+// format: jpg, png or gif.
+// width: The image width.
+// height: The image height.
+//
+// Returns:
+// An IIIFImageRequest.
+// An ErrValidation error if any param is not valid.
+func ParseIIIFImageRequest(region, size, rotation, quality, format string, width, height int) (*IIIFImageRequest, error) {
+	req := &IIIFImageRequest{Quality: quality, Format: format}
+
+	var err error
+	if req.Region, err = parseIIIFRegion(region, width, height); err != nil {
+		return nil, err
+	}
+
+	if req.Width, req.Height, err = parseIIIFSize(size, req.Region.Dx(), req.Region.Dy()); err != nil {
+		return nil, err
+	}
+
+	req.Mirror = strings.HasPrefix(rotation, "!")
+	req.Rotation, err = strconv.ParseFloat(strings.TrimPrefix(rotation, "!"), 64)
+	if err != nil || req.Rotation < 0 || req.Rotation > 360 {
+		return nil, newError(ErrValidation, "The rotation %s is not valid", rotation)
+	}
+
+	switch quality {
+	case "default", "color", "gray", "bitonal":
+	default:
+		return nil, newError(ErrValidation, "The quality %s is not valid", quality)
+	}
+
+	if _, ok := iiifFormats[format]; !ok {
+		return nil, newError(ErrValidation, "The format %s is not supported", format)
+	}
+
+	return req, nil
+}
+
+// parseIIIFRegion returns the requested region of an image, regions exceeding
+// the image are cropped.
+func parseIIIFRegion(region string, width, height int) (image.Rectangle, error) {
+	bounds := image.Rect(0, 0, width, height)
+
+	switch {
+	case region == "full":
+		return bounds, nil
+	case region == "square":
+		side := width
+		if height < side {
+			side = height
+		}
+		x, y := (width-side)/2, (height-side)/2
+		return image.Rect(x, y, x+side, y+side), nil
+	}
+
+	values, pct := iiifNumbers(strings.TrimPrefix(region, "pct:")), strings.HasPrefix(region, "pct:")
+	if len(values) != 4 || values[2] <= 0 || values[3] <= 0 {
+		return image.Rectangle{}, newError(ErrValidation, "The region %s is not valid", region)
+	}
+
+	if pct {
+		values[0], values[2] = values[0]*float64(width)/100, values[2]*float64(width)/100
+		values[1], values[3] = values[1]*float64(height)/100, values[3]*float64(height)/100
+	} else if strings.Contains(region, ".") {
+		return image.Rectangle{}, newError(ErrValidation, "The region %s is not valid", region)
+	}
+
+	rect := image.Rect(
+		int(math.Round(values[0])), int(math.Round(values[1])),
+		int(math.Round(values[0]+values[2])), int(math.Round(values[1]+values[3]))).Intersect(bounds)
+	if rect.Empty() {
+		return image.Rectangle{}, newError(ErrValidation, "The region %s is outside the image", region)
+	}
+
+	return rect, nil
+}
+
+// parseIIIFSize returns the requested width and height for a region of the
+// given width and height, the max size is the largest one within the IIIF
+// limits.
+func parseIIIFSize(size string, width, height int) (int, int, error) {
+	upscale := strings.HasPrefix(size, "^")
+	spec := strings.TrimPrefix(size, "^")
+
+	invalid := newError(ErrValidation, "The size %s is not valid", size)
+
+	var w, h float64
+	switch {
+	case spec == "max":
+		scale := math.Min(float64(IIIFMaxWidth)/float64(width), float64(IIIFMaxHeight)/float64(height))
+		scale = math.Min(scale, math.Sqrt(float64(IIIFMaxArea)/(float64(width)*float64(height))))
+		if !upscale {
+			scale = math.Min(scale, 1)
+		}
+		w, h = math.Floor(float64(width)*scale), math.Floor(float64(height)*scale)
+	case strings.HasPrefix(spec, "pct:"):
+		values := iiifNumbers(strings.TrimPrefix(spec, "pct:"))
+		if len(values) != 1 || values[0] <= 0 {
+			return 0, 0, invalid
+		}
+		w, h = float64(width)*values[0]/100, float64(height)*values[0]/100
+	default:
+		confined := strings.HasPrefix(spec, "!")
+		parts := strings.Split(strings.TrimPrefix(spec, "!"), ",")
+		if len(parts) != 2 || strings.Contains(spec, ".") {
+			return 0, 0, invalid
+		}
+
+		pw, errW := strconv.Atoi(parts[0])
+		ph, errH := strconv.Atoi(parts[1])
+
+		switch {
+		case confined && errW == nil && errH == nil && pw > 0 && ph > 0:
+			scale := math.Min(float64(pw)/float64(width), float64(ph)/float64(height))
+			if !upscale {
+				scale = math.Min(scale, 1)
+			}
+			w, h = float64(width)*scale, float64(height)*scale
+		case confined:
+			return 0, 0, invalid
+		case parts[1] == "" && errW == nil && pw > 0:
+			w, h = float64(pw), float64(height)*float64(pw)/float64(width)
+		case parts[0] == "" && errH == nil && ph > 0:
+			w, h = float64(width)*float64(ph)/float64(height), float64(ph)
+		case errW == nil && errH == nil && pw > 0 && ph > 0:
+			w, h = float64(pw), float64(ph)
+		default:
+			return 0, 0, invalid
+		}
+	}
+
+	w, h = math.Max(1, math.Round(w)), math.Max(1, math.Round(h))
+	if !upscale && (w > float64(width) || h > float64(height)) {
+		return 0, 0, newError(ErrValidation, "The size %s requires upscaling, it should be prefixed by ^", size)
+	}
+
+	// The limits are checked before the int conversion, so huge sizes can't
+	// overflow.
+	if w > float64(IIIFMaxWidth) || h > float64(IIIFMaxHeight) || w*h > float64(IIIFMaxArea) {
+		return 0, 0, newError(ErrValidation, "The size %s exceeds the maximum %dx%d or %d pixels",
+			size, IIIFMaxWidth, IIIFMaxHeight, IIIFMaxArea)
+	}
+
+	return int(w), int(h), nil
+}
+
+// iiifNumbers parses a comma separated list of non negative numbers, it
+// returns nil if any of them is not valid.
+func iiifNumbers(list string) []float64 {
+	var numbers []float64
+
+	for _, part := range strings.Split(list, ",") {
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil || number < 0 {
+			return nil
+		}
+		numbers = append(numbers, number)
+	}
+
+	return numbers
+}
+
+// Apply returns the image resulting of the IIIF Image API request, in order:
+// region, size, rotation (mirroring first) and quality.
+//
+// img: The full image, with its EXIF orientation already applied.
+//
+// Returns the resulting image.
+func (req *IIIFImageRequest) Apply(img image.Image) image.Image {
+	result := imaging.Crop(img, req.Region)
+
+	if req.Width != req.Region.Dx() || req.Height != req.Region.Dy() {
+		result = imaging.Resize(result, req.Width, req.Height, imaging.Lanczos)
+	}
+
+	if req.Mirror {
+		result = imaging.FlipH(result)
+	}
+
+	switch req.Rotation {
+	case 0, 360:
+	case 90:
+		result = imaging.Rotate270(result)
+	case 180:
+		result = imaging.Rotate180(result)
+	case 270:
+		result = imaging.Rotate90(result)
+	default:
+		// imaging rotates counter-clockwise, IIIF clockwise.
+		result = imaging.Rotate(result, 360-req.Rotation, color.Transparent)
+	}
+
+	switch req.Quality {
+	case "gray":
+		result = imaging.Grayscale(result)
+	case "bitonal":
+		gray := imaging.Grayscale(result)
+		result = imaging.AdjustFunc(gray, func(c color.NRGBA) color.NRGBA {
+			if c.R < 128 {
+				return color.NRGBA{0, 0, 0, c.A}
+			}
+			return color.NRGBA{255, 255, 255, c.A}
+		})
+	}
+
+	return result
+}
+
+// Encode writes the resulting image on the requested format.
+//
+// w: The writer.
+// img: The image resulting of Apply.
+//
+// Returns an error if any.
+func (req *IIIFImageRequest) Encode(w io.Writer, img image.Image) error {
+	return imaging.Encode(w, img, iiifFormats[req.Format].format, imaging.JPEGQuality(85))
+}
+
+// ContentType returns the media type of the requested format.
+func (req *IIIFImageRequest) ContentType() string {
+	return iiifFormats[req.Format].contentType
+}
+
+// decodeImage decodes an Artwork image applying its EXIF orientation, its
+// size is cached for later iiifSize calls. Images exceeding the client
+// MaxImagePixels are not decoded.
+//
+// ctx: The request context.
+// artworksClient: The Artworks client.
+// img: The Image to decode.
+//
+// Returns:
+// The decoded image.
+// An error otherwise.
+func decodeImage(ctx context.Context, artworksClient ArtworksController, img *Image) (image.Image, error) {
+	decoded, err := artworksClient.DecodeImage(ctx, img)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode the Image %d. Err: %s", img.ID, err)
+	}
+
+	iiifSizes.Store(img.StorageKey, decoded.Bounds().Size())
+
+	return decoded, nil
+}
+
+// iiifSize returns the width and height of an Artwork image, as shown once
+// its EXIF orientation is applied.
+func iiifSize(ctx context.Context, artworksClient ArtworksController, img *Image) (image.Point, error) {
+	if size, ok := iiifSizes.Load(img.StorageKey); ok {
+		return size.(image.Point), nil
+	}
+
+	decoded, err := decodeImage(ctx, artworksClient, img)
+	if err != nil {
+		return image.Point{}, err
+	}
+
+	return decoded.Bounds().Size(), nil
+}
+
+// requestBaseURL returns the scheme and host the request was sent to, the
+// X-Forwarded-Proto header set by the proxies is honoured.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	return scheme + "://" + r.Host
+}
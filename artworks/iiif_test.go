@@ -0,0 +1,212 @@
+package artworks
+
+import (
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestParseIIIFImageRequest(t *testing.T) {
+	tests := []struct {
+		region, size, rotation string
+		expectedRegion         image.Rectangle
+		expectedSize           image.Point
+		expectedError          bool
+	}{
+		{region: "full", size: "max", rotation: "0", expectedRegion: image.Rect(0, 0, 400, 200), expectedSize: image.Pt(400, 200)},
+		{region: "square", size: "100,", rotation: "90", expectedRegion: image.Rect(100, 0, 300, 200), expectedSize: image.Pt(100, 100)},
+		{region: "10,20,100,50", size: ",25", rotation: "!0", expectedRegion: image.Rect(10, 20, 110, 70), expectedSize: image.Pt(50, 25)},
+		{region: "pct:50,50,50,50", size: "pct:50", rotation: "180", expectedRegion: image.Rect(200, 100, 400, 200), expectedSize: image.Pt(100, 50)},
+		{region: "300,100,500,500", size: "!50,50", rotation: "45", expectedRegion: image.Rect(300, 100, 400, 200), expectedSize: image.Pt(50, 50)},
+		{region: "full", size: "^800,", rotation: "0", expectedRegion: image.Rect(0, 0, 400, 200), expectedSize: image.Pt(800, 400)},
+		{region: "full", size: "!800,800", rotation: "0", expectedRegion: image.Rect(0, 0, 400, 200), expectedSize: image.Pt(400, 200)},
+		{region: "full", size: "800,", rotation: "0", expectedError: true},
+		{region: "full", size: "full", rotation: "0", expectedError: true},
+		{region: "500,500,10,10", size: "max", rotation: "0", expectedError: true},
+		{region: "0,0,0,10", size: "max", rotation: "0", expectedError: true},
+		{region: "full", size: "max", rotation: "361", expectedError: true},
+		{region: "full", size: "^max", rotation: "0", expectedRegion: image.Rect(0, 0, 400, 200), expectedSize: image.Pt(7071, 3535)},
+		{region: "full", size: "^10001,", rotation: "0", expectedError: true},
+		{region: "full", size: "^pct:2000", rotation: "0", expectedError: true},
+		{region: "full", size: "^99999999999999999999,", rotation: "0", expectedError: true},
+	}
+
+	for _, test := range tests {
+		req, err := ParseIIIFImageRequest(test.region, test.size, test.rotation, "default", "jpg", 400, 200)
+		if (err != nil) != test.expectedError {
+			t.Errorf("The returned error don't match the test case for %s/%s/%s. Got: %v", test.region, test.size, test.rotation, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if req.Region != test.expectedRegion || image.Pt(req.Width, req.Height) != test.expectedSize {
+			t.Errorf("The request for %s/%s don't match Got: %v %dx%d Expected: %v %v",
+				test.region, test.size, req.Region, req.Width, req.Height, test.expectedRegion, test.expectedSize)
+		}
+	}
+
+	for _, params := range [][2]string{{"best", "jpg"}, {"default", "tif"}} {
+		if _, err := ParseIIIFImageRequest("full", "max", "0", params[0], params[1], 400, 200); err == nil {
+			t.Errorf("The quality %s and format %s should not be valid", params[0], params[1])
+		}
+	}
+}
+
+func TestParseIIIFImageRequestMax(t *testing.T) {
+	req, err := ParseIIIFImageRequest("full", "max", "0", "default", "jpg", 20000, 10000)
+	if err != nil {
+		t.Errorf("ParseIIIFImageRequest returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if image.Pt(req.Width, req.Height) != image.Pt(7071, 3535) {
+		t.Errorf("The max size should fit the IIIF limits Got: %dx%d Expected: 7071x3535", req.Width, req.Height)
+	}
+}
+
+func TestIIIFImageRequestApply(t *testing.T) {
+	req, _ := ParseIIIFImageRequest("0,0,200,100", "100,", "90", "gray", "png", 400, 200)
+
+	result := req.Apply(image.NewRGBA(image.Rect(0, 0, 400, 200)))
+	if size := result.Bounds().Size(); size != image.Pt(50, 100) {
+		t.Errorf("The resulting image size don't match Got: %v Expected: (50,100)", size)
+	}
+}
+
+func TestGetIIIFImageHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/iiif/artworks/{id:[0-9]+}/images/{image:[0-9]+}/info.json", ProblemHandler(GetIIIFInfoHandler(&FakeClient{})))
+	r.Handle("/iiif/artworks/{id:[0-9]+}/images/{image:[0-9]+}/{region}/{size}/{rotation}/{quality:[a-z]+}.{format:[a-z]+}",
+		ProblemHandler(GetIIIFImageHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/iiif/artworks/1/images/1/info.json")
+	if err != nil {
+		t.Errorf("Unable to perform GetIIIFInfo request. Err: %s", err)
+		return
+	}
+
+	var info IIIFInfo
+	json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+
+	if info.ID != server.URL+"/iiif/artworks/1/images/1" || info.Width != 4 || info.Height != 2 ||
+		info.MaxWidth != IIIFMaxWidth || info.MaxHeight != IIIFMaxHeight || info.MaxArea != IIIFMaxArea {
+		t.Errorf("The image information don't match the mocked Image. Got: %+v", info)
+	}
+
+	tests := []struct {
+		path       string
+		statusCode int
+		size       image.Point
+	}{
+		{path: "/full/max/0/default.jpg", statusCode: http.StatusOK, size: image.Pt(4, 2)},
+		{path: "/square/max/90/gray.png", statusCode: http.StatusOK, size: image.Pt(2, 2)},
+		{path: "/full/^8,/!90/bitonal.png", statusCode: http.StatusOK, size: image.Pt(4, 8)},
+		{path: "/full/8,/0/default.jpg", statusCode: http.StatusBadRequest},
+		{path: "/full/max/0/best.jpg", statusCode: http.StatusBadRequest},
+		{path: "/full/^pct:500000/0/default.jpg", statusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + "/iiif/artworks/1/images/1" + test.path)
+		if err != nil {
+			t.Errorf("Unable to perform GetIIIFImage request. Err: %s", err)
+			return
+		}
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.path, resp.StatusCode, test.statusCode)
+			resp.Body.Close()
+			continue
+		}
+
+		if test.statusCode == http.StatusOK {
+			decode := png.DecodeConfig
+			if resp.Header.Get("Content-Type") == "image/jpeg" {
+				decode = jpeg.DecodeConfig
+			}
+
+			config, err := decode(resp.Body)
+			if err != nil || image.Pt(config.Width, config.Height) != test.size {
+				t.Errorf("The image for %s don't match Got: %dx%d Expected: %v. Err: %v", test.path, config.Width, config.Height, test.size, err)
+			}
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestGetIIIFImageHandlerMaxPixels(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/iiif/artworks/{id:[0-9]+}/images/{image:[0-9]+}/{region}/{size}/{rotation}/{quality:[a-z]+}.{format:[a-z]+}",
+		ProblemHandler(GetIIIFImageHandler(&FakeClient{MaxImagePixels: 4})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/iiif/artworks/1/images/1/full/max/0/default.jpg")
+	if err != nil {
+		t.Errorf("Unable to perform GetIIIFImage request. Err: %s", err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("The response Status Code don't match for a 4x2 image Got: %d Expected: %d",
+			resp.StatusCode, http.StatusInternalServerError)
+	}
+}
+
+func TestGetIIIFManifestHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/iiif/artworks/{id:[0-9]+}/manifest.json", ProblemHandler(GetIIIFManifestHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/iiif/artworks/1/manifest.json")
+	if err != nil {
+		t.Errorf("Unable to perform GetIIIFManifest request. Err: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var manifest IIIFManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		t.Errorf("Unable to decode the IIIF manifest. Err: %s", err)
+		return
+	}
+
+	if manifest.Type != "Manifest" || manifest.ID != server.URL+"/iiif/artworks/1/manifest.json" {
+		t.Errorf("The IIIF manifest don't match the expected. Got: %+v", manifest)
+	}
+
+	if len(manifest.Items) != 1 || manifest.Items[0].Width != 4 || manifest.Items[0].Height != 2 {
+		t.Errorf("The IIIF manifest canvases don't match the mocked Image. Got: %+v", manifest.Items)
+	}
+}
+
+func TestNewIIIFManifestMetadata(t *testing.T) {
+	artwork := &Artwork{ID: 1, Rei: "#EU82REE", Tit: "Retrato de dama", Aut: "Anónimo", Dim: "60 x 45 cm"}
+
+	manifest := NewIIIFManifest(artwork, nil, nil, "https://artworks.example.com")
+
+	if manifest.Label["es"][0] != "Retrato de dama" || manifest.Summary != nil {
+		t.Errorf("The IIIF manifest label and summary don't match. Got: %v %v", manifest.Label, manifest.Summary)
+	}
+
+	if len(manifest.Metadata) != 3 || manifest.Metadata[1].Value["es"][0] != "Anónimo" {
+		t.Errorf("The IIIF manifest metadata don't match the Artwork fields. Got: %+v", manifest.Metadata)
+	}
+}
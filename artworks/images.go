@@ -0,0 +1,435 @@
+package artworks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/disintegration/imaging"
+
+	// The decoders of the accepted image types, checkImagePixels reads the
+	// images dimensions with them. The WebP one is registered by the
+	// derivatives encoder package.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// MaxImageSize is the maximum size in bytes of an uploaded Artwork image.
+var MaxImageSize int64 = 32 << 20
+
+// DefaultMaxImagePixels is the maximum width × height of an Artwork image
+// used when the Client MaxImagePixels isn't set.
+const DefaultMaxImagePixels = 100000000
+
+// multipartOverhead is the room left for the multipart/form-data encoding
+// when limiting the upload requests size.
+const multipartOverhead = 1 << 20
+
+// sniffLen is the amount of bytes used to sniff the images type.
+const sniffLen = 512
+
+// ErrImageTooLarge is returned when an uploaded image exceeds MaxImageSize.
+var ErrImageTooLarge = errors.New("The image exceeds the maximum size")
+
+// imageContentTypes are the accepted image types, as sniffed from the
+// uploaded content.
+var imageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Image is a photograph attached to an Artwork, the content is kept on the
+// Client Storage and the metadata on the artwork_images table.
+//
+// Example:
+// {
+//   id: 1,
+//   artwork_id: 1,
+//   filename: 'puerto-mahon-frontal.jpg',
+//   content_type: 'image/jpeg',
+//   size: 2483712,
+//   created_at: 1489140631
+// }
+type Image struct {
+	ID          int    `json:"id"`
+	ArtworkID   int    `json:"artwork_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	CreatedAt   int64  `json:"created_at"`
+	StorageKey  string `json:"-"`
+}
+
+// imageColumns is the explicit column list used on the artwork_images table
+// SELECT statements.
+const imageColumns = "id, artwork_id, filename, content_type, size, storage_key, created_at"
+
+// AddImage stores a new image for an existing Artwork, its derivatives are
+// enqueued on the Client DerivativeWorker if any.
+//
+// image: The image metadata, the ArtworkID, Filename and ContentType should
+// be set. The ID, Size and CreatedAt are set once stored.
+// content: The image content.
+//
+// Returns an error if any, ErrImageTooLarge if the content exceeds
+// MaxImageSize, an ErrValidation one if it exceeds the Client MaxImagePixels.
+func (c *Client) AddImage(ctx context.Context, image *Image, content io.Reader) error {
+	if _, err := c.GetArtwork(ctx, image.ArtworkID); err != nil {
+		return err
+	}
+
+	key, err := newStorageKey(image.ArtworkID)
+	if err != nil {
+		return err
+	}
+
+	checked, err := checkImagePixels(&maxSizeReader{r: content, remaining: MaxImageSize}, c.MaxImagePixels)
+	if err != nil {
+		return err
+	}
+
+	size, err := c.Storage.Put(key, checked)
+	if errors.Is(err, ErrImageTooLarge) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to store the Image content. Err: %w", err)
+	}
+
+	image.Size = size
+	image.StorageKey = key
+	image.CreatedAt = time.Now().Unix()
+
+	// The upload isn't bound by the timeout, only the database is.
+	ctx, cancel := c.withTimeout(ctx, "AddImage")
+	defer cancel()
+
+	res, err := c.DB.ExecContext(ctx,
+		"INSERT INTO artwork_images (artwork_id, filename, content_type, size, storage_key, created_at) "+
+			"VALUES (?, ?, ?, ?, ?, ?)",
+		image.ArtworkID, image.Filename, image.ContentType, image.Size, image.StorageKey, image.CreatedAt)
+	if err != nil {
+		c.Storage.Delete(key)
+		return fmt.Errorf("Unable to execute the Image INSERT statement. Err: %w", err)
+	}
+
+	ID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("Unable to fetch the inserted Image ID. Err: %w", err)
+	}
+	image.ID = int(ID)
+
+	if c.Derivatives != nil {
+		c.Derivatives.Enqueue(*image)
+	}
+
+	return nil
+}
+
+// GetImages returns the images of an Artwork, sorted by upload.
+//
+// artworkID: The Artwork id.
+//
+// Returns:
+// An array of Images.
+// An error otherwise.
+func (c *Client) GetImages(ctx context.Context, artworkID int) ([]Image, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetImages")
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx,
+		"SELECT "+imageColumns+" FROM artwork_images WHERE artwork_id=? ORDER BY id", artworkID)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artwork_images table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	images := make([]Image, 0)
+
+	for rows.Next() {
+		var image Image
+		if err := scanImage(rows, &image); err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Images data. Err %w", err)
+	}
+
+	return images, nil
+}
+
+// GetImage returns a single image of an Artwork.
+//
+// artworkID: The Artwork id.
+// imageID: The Image id.
+//
+// Returns:
+// An Image.
+// An error otherwise.
+func (c *Client) GetImage(ctx context.Context, artworkID int, imageID int) (*Image, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetImage")
+	defer cancel()
+
+	var image Image
+
+	err := scanImage(c.DB.QueryRowContext(ctx,
+		"SELECT "+imageColumns+" FROM artwork_images WHERE artwork_id=? AND id=?", artworkID, imageID), &image)
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, "Unable to find the Image %d of the Artwork with id: %d", imageID, artworkID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+// DecodeImage decodes an image from the Client Storage applying its EXIF
+// orientation, images exceeding the Client MaxImagePixels are not decoded.
+//
+// img: The Image to decode.
+//
+// Returns:
+// The decoded image.
+// An error otherwise.
+func (c *Client) DecodeImage(ctx context.Context, img *Image) (image.Image, error) {
+	content, err := c.Storage.Get(img.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return decodeImageContent(content, c.MaxImagePixels)
+}
+
+// OpenImage opens the content of an image from the Client Storage.
+//
+// image: The Image to open.
+//
+// Returns:
+// The Image content, it should be closed by the caller.
+// An error otherwise.
+func (c *Client) OpenImage(ctx context.Context, image *Image) (io.ReadCloser, error) {
+	return c.Storage.Get(image.StorageKey)
+}
+
+// DeleteImage removes an image of an Artwork, both the metadata and the
+// stored content, derivatives included.
+//
+// artworkID: The Artwork id.
+// imageID: The Image id.
+//
+// Returns an error if any.
+func (c *Client) DeleteImage(ctx context.Context, artworkID int, imageID int) error {
+	ctx, cancel := c.withTimeout(ctx, "DeleteImage")
+	defer cancel()
+
+	image, err := c.GetImage(ctx, artworkID, imageID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.DB.ExecContext(ctx, "DELETE FROM artwork_images WHERE id=?", image.ID); err != nil {
+		return fmt.Errorf("Unable to execute the Image DELETE statement. Err: %w", err)
+	}
+
+	return c.deleteContents([]string{image.StorageKey})
+}
+
+// deleteImages removes the images metadata of an Artwork on the given
+// transaction, the stored content should be deleted with deleteContents once
+// the transaction is committed.
+//
+// tx: The transaction.
+// artworkID: The Artwork id.
+//
+// Returns:
+// The Storage keys of the removed images.
+// An error otherwise.
+func deleteImages(ctx context.Context, tx *sql.Tx, artworkID int) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT storage_key FROM artwork_images WHERE artwork_id=? FOR UPDATE", artworkID)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artwork_images table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("Unable to map an Image data row. Err: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Images data. Err %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM artwork_images WHERE artwork_id=?", artworkID); err != nil {
+		return nil, fmt.Errorf("Unable to execute the Image DELETE statement. Err: %w", err)
+	}
+
+	return keys, nil
+}
+
+// deleteContents removes the given image keys from the Client Storage, along
+// with their derivatives.
+//
+// Returns the first error if any, every key is tried anyway.
+func (c *Client) deleteContents(keys []string) error {
+	var first error
+
+	for _, key := range keys {
+		for _, key := range append([]string{key}, derivativeKeys(key)...) {
+			if err := c.Storage.Delete(key); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+
+	return first
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+// scanImage maps a data row selecting the imageColumns into the given Image.
+func scanImage(row scanner, image *Image) error {
+	err := row.Scan(&image.ID, &image.ArtworkID, &image.Filename, &image.ContentType,
+		&image.Size, &image.StorageKey, &image.CreatedAt)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to map an Image data row. Err: %w", err)
+	}
+
+	return nil
+}
+
+// imageFilename returns a safe filename for an uploaded image, the client
+// paths are dropped.
+func imageFilename(name string) string {
+	name = filepath.Base(filepath.FromSlash(name))
+	if name == "." || name == string(filepath.Separator) {
+		return ""
+	}
+
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:])
+	}
+
+	return name
+}
+
+// maxSizeReader reads up to remaining bytes, it fails with ErrImageTooLarge
+// once the content exceeds them.
+type maxSizeReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// Read implements the io.Reader interface.
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return 0, ErrImageTooLarge
+	}
+
+	return n, err
+}
+
+// checkImagePixels reads the image dimensions from its header, without
+// decoding it, so a small compressed file can't be decoded into a huge
+// bitmap.
+//
+// content: The image content.
+// maxPixels: The maximum width × height, DefaultMaxImagePixels if it's not
+// positive.
+//
+// Returns:
+// A reader with the whole image content, the header included.
+// An ErrValidation error if the dimensions can't be read or they exceed
+// maxPixels, the content read error if any, as ErrImageTooLarge.
+func checkImagePixels(content io.Reader, maxPixels int) (io.Reader, error) {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxImagePixels
+	}
+
+	var header bytes.Buffer
+	reader := &readErrorReader{r: io.TeeReader(content, &header)}
+
+	// The decoders hide the read errors as format ones, they are reported
+	// as they are.
+	config, _, err := image.DecodeConfig(reader)
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	if err != nil {
+		return nil, newError(ErrValidation, "Unable to read the image dimensions. Err: %s", err)
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return nil, newError(ErrValidation, "The image is %dx%d pixels, it exceeds the maximum of %d pixels",
+			config.Width, config.Height, maxPixels)
+	}
+
+	return io.MultiReader(&header, content), nil
+}
+
+// decodeImageContent decodes an image applying its EXIF orientation, the
+// image dimensions are checked before decoding it.
+//
+// content: The image content.
+// maxPixels: The maximum width × height, as on checkImagePixels.
+//
+// Returns:
+// The decoded image.
+// An error otherwise.
+func decodeImageContent(content io.Reader, maxPixels int) (image.Image, error) {
+	checked, err := checkImagePixels(content, maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	return imaging.Decode(checked, imaging.AutoOrientation(true))
+}
+
+// readErrorReader keeps the first read error, io.EOF aside.
+type readErrorReader struct {
+	r   io.Reader
+	err error
+}
+
+// Read implements the io.Reader interface.
+func (rer *readErrorReader) Read(p []byte) (int, error) {
+	n, err := rer.r.Read(p)
+	if err != nil && err != io.EOF && rer.err == nil {
+		rer.err = err
+	}
+
+	return n, err
+}
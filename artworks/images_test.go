@@ -0,0 +1,178 @@
+package artworks
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artworks-images")
+	if err != nil {
+		t.Errorf("Unable to create the storage directory. Err: %s", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	storage := &LocalStorage{Dir: dir}
+
+	size, err := storage.Put("artworks/1/image", strings.NewReader(fakeImageContent))
+	if err != nil || size != int64(len(fakeImageContent)) {
+		t.Errorf("Unable to store the content. Size: %d Err: %v", size, err)
+		return
+	}
+
+	content, err := storage.Get("../../artworks/1/image")
+	if err != nil {
+		t.Errorf("Unable to open the stored content. Err: %s", err)
+		return
+	}
+	data, _ := ioutil.ReadAll(content)
+	content.Close()
+
+	if string(data) != fakeImageContent {
+		t.Errorf("The stored content don't match Got: %q Expected: %q", data, fakeImageContent)
+	}
+
+	if err := storage.Delete("artworks/1/image"); err != nil {
+		t.Errorf("Unable to delete the stored content. Err: %s", err)
+	}
+
+	if _, err := storage.Get("artworks/1/image"); err == nil {
+		t.Errorf("A deleted content should not be found")
+	}
+
+	if err := storage.Delete("artworks/1/image"); err != nil {
+		t.Errorf("Deleting a missing content should be a no-op. Err: %s", err)
+	}
+}
+
+func TestAddImageHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}/images", ProblemHandler(AddImageHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	maxImageSize := MaxImageSize
+	MaxImageSize = 128
+	defer func() { MaxImageSize = maxImageSize }()
+
+	tests := []struct {
+		field      string
+		content    string
+		statusCode int
+	}{
+		{field: "image", content: fakeImageContent, statusCode: http.StatusCreated},
+		{field: "image", content: "est=Bueno", statusCode: http.StatusUnsupportedMediaType},
+		{field: "image", content: fakeImageContent + strings.Repeat("0", 128), statusCode: http.StatusRequestEntityTooLarge},
+		{field: "photo", content: fakeImageContent, statusCode: http.StatusBadRequest},
+		{field: "image", content: fakeImageContent[:16], statusCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile(test.field, "../puerto-mahon-frontal.png")
+		part.Write([]byte(test.content))
+		writer.Close()
+
+		resp, err := http.Post(server.URL+"/artworks/1/images", writer.FormDataContentType(), &body)
+		if err != nil {
+			t.Errorf("Unable to perform AddImage request. Err: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %q Got: %d Expected: %d", test.content, resp.StatusCode, test.statusCode)
+		}
+
+		if test.statusCode == http.StatusCreated && resp.Header.Get("Location") != "/artworks/1/images/1" {
+			t.Errorf("The Location header don't match Got: %s Expected: /artworks/1/images/1", resp.Header.Get("Location"))
+		}
+	}
+}
+
+func TestAddImageHandlerMaxPixels(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}/images", ProblemHandler(AddImageHandler(&FakeClient{MaxImagePixels: 4})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("image", "puerto-mahon-frontal.png")
+	part.Write([]byte(fakeImageContent))
+	writer.Close()
+
+	resp, err := http.Post(server.URL+"/artworks/1/images", writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Errorf("Unable to perform AddImage request. Err: %s", err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("The response Status Code don't match for a 4x2 image Got: %d Expected: %d",
+			resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestGetImageHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}/images/{image:[0-9]+}", ProblemHandler(GetImageHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/artworks/1/images/1")
+	if err != nil {
+		t.Errorf("Unable to perform GetImage request. Err: %s", err)
+		return
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(data) != fakeImageContent {
+		t.Errorf("The response don't match the mocked Image. Got: %d %q", resp.StatusCode, data)
+	}
+
+	if resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("The response Content-Type don't match Got: %s Expected: image/png", resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(server.URL + "/artworks/1/images/2")
+	if err != nil {
+		t.Errorf("Unable to perform GetImage request. Err: %s", err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("The response Status Code don't match Got: %d Expected: %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestImageFilename(t *testing.T) {
+	tests := map[string]string{
+		"puerto-mahon.jpg":             "puerto-mahon.jpg",
+		"../../etc/puerto-mahon.jpg":   "puerto-mahon.jpg",
+		"C:/fotos/retrato de dama.png": "retrato de dama.png",
+		"":                             "",
+	}
+
+	for name, expected := range tests {
+		if filename := imageFilename(name); filename != expected {
+			t.Errorf("The image filename for %q don't match Got: %q Expected: %q", name, filename, expected)
+		}
+	}
+}
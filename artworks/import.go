@@ -0,0 +1,422 @@
+package artworks
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxImportRows is the maximum amount of Artworks on a single import.
+	MaxImportRows = 10000
+
+	// MaxImportSize is the maximum size in bytes of an import request body.
+	MaxImportSize = 16 << 20
+
+	// importBatchSize is the amount of Artworks inserted per statement.
+	importBatchSize = 100
+)
+
+// ImportRecord is an Artwork read from a CSV import, along with its row so
+// every failure, validation and conflict ones, is reported on the same row.
+type ImportRecord struct {
+	// Row is the CSV row number, from 1 with the header excluded.
+	Row     int
+	Artwork *Artwork
+}
+
+// importColumns are the columns that could be filled by an import, all of
+// them text ones, the creation date is the import one and imported Artworks
+// are never publicable until they are reviewed.
+var importColumns = columnsExcept(insertColumns, "created_at", "publicable")
+
+// ImportOptions contains the settings to read an Artworks import.
+//
+// Mapping tells the Artwork JSON field name by CSV header, headers that are
+// already Artwork field names don't need to be mapped.
+type ImportOptions struct {
+	DryRun    bool
+	Delimiter rune
+	Mapping   map[string]string
+}
+
+// ImportResult is the outcome of a valid Artworks import.
+//
+// Example:
+// {
+//   dry_run: false,
+//   total: 2,
+//   ids: [3, 4]
+// }
+type ImportResult struct {
+	DryRun bool  `json:"dry_run"`
+	Total  int   `json:"total"`
+	IDs    []int `json:"ids,omitempty"`
+}
+
+// ParseImportOptions builds an ImportOptions from the given URL query values.
+//
+// Available params:
+//
+// 'dry_run': true to validate the import without storing it.
+// 'delimiter': The CSV delimiter, one of ',' (the default), ';' or 'tab'.
+// 'map': A 'header:field' mapping, it could be repeated, as in
+// map=Título:tit&map=Autor:aut.
+//
+// query: The URL query values.
+//
+// Returns:
+// The parsed ImportOptions.
+// An error if any of the params is not valid.
+func ParseImportOptions(query url.Values) (*ImportOptions, error) {
+	opts := &ImportOptions{
+		Delimiter: ',',
+		Mapping:   map[string]string{},
+	}
+
+	if dryRun := query.Get("dry_run"); dryRun != "" {
+		if dryRun != "true" && dryRun != "false" {
+			return nil, fmt.Errorf("The dry_run param should be either true or false")
+		}
+		opts.DryRun = dryRun == "true"
+	}
+
+	switch query.Get("delimiter") {
+	case "", ",":
+	case ";":
+		opts.Delimiter = ';'
+	case "tab":
+		opts.Delimiter = '\t'
+	default:
+		return nil, fmt.Errorf("The delimiter param should be one of ',', ';' or 'tab'")
+	}
+
+	for _, mapping := range query["map"] {
+		parts := strings.SplitN(mapping, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("The map param %s should be a header:field pair", mapping)
+		}
+
+		if _, ok := importColumn(parts[1]); !ok {
+			return nil, fmt.Errorf("The map param field %s doesn't exist or can't be imported", parts[1])
+		}
+		opts.Mapping[normalizeHeader(parts[0])] = parts[1]
+	}
+
+	return opts, nil
+}
+
+// ParseImport reads the Artworks of a CSV import, its first row should be the
+// header. Every row is validated against the Artwork JSON Schema and the rei
+// can't be repeated, all the failures are reported at once.
+//
+// Rows are numbered from 1, the header excluded. Rows without values are
+// skipped. The rei are compared case insensitively, as the database does.
+//
+// r: The CSV content, UTF-8 encoded.
+// opts: The import settings.
+//
+// Returns:
+// The ImportRecords, holding the Artworks to import.
+// An error if the CSV can't be read, an ErrValidation one holding the
+// failures by row and field otherwise.
+func ParseImport(r io.Reader, opts *ImportOptions) ([]ImportRecord, error) {
+	reader := bufio.NewReader(r)
+	if bom, _ := reader.Peek(3); string(bom) == "\xef\xbb\xbf" {
+		reader.Discard(3)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = opts.Delimiter
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, newError(ErrValidation, "The import is empty, it should have a header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the import header. Err: %w", err)
+	}
+
+	cols, err := headerColumns(header, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []ImportRecord
+	var failures []FieldError
+	reis := map[string]int{}
+	createdAt := time.Now().Unix()
+
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the import row %d. Err: %w", row, err)
+		}
+
+		if row > MaxImportRows {
+			return nil, newError(ErrValidation, "The import exceeds the maximum of %d rows", MaxImportRows)
+		}
+
+		if isBlank(record) {
+			continue
+		}
+
+		if len(record) > len(cols) {
+			failures = append(failures, FieldError{Row: row, Message: "The row has more values than the header"})
+			continue
+		}
+
+		artwork := &Artwork{CreatedAt: createdAt}
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if !utf8.ValidString(value) {
+				failures = append(failures, FieldError{Row: row, Field: cols[i].name, Message: "The value is not UTF-8 encoded"})
+			}
+			*cols[i].field(artwork).(*string) = value
+		}
+
+		if err := ValidateArtwork(artwork); err != nil {
+			for _, failure := range err.(*Error).Fields {
+				failure.Row = row
+				failures = append(failures, failure)
+			}
+		}
+
+		if previous, ok := reis[reiKey(artwork.Rei)]; ok && artwork.Rei != "" {
+			failures = append(failures, FieldError{
+				Row:     row,
+				Field:   "rei",
+				Message: fmt.Sprintf("The rei %s is repeated, it's already on the row %d", artwork.Rei, previous),
+			})
+		}
+		reis[reiKey(artwork.Rei)] = row
+
+		records = append(records, ImportRecord{Row: row, Artwork: artwork})
+	}
+
+	if len(failures) > 0 {
+		return nil, &Error{
+			Kind:   ErrValidation,
+			Detail: fmt.Sprintf("The import has %d errors", len(failures)),
+			Fields: failures,
+		}
+	}
+
+	return records, nil
+}
+
+// ImportArtworks inserts a batch of new Artworks on a single transaction, so
+// either all of them are stored or none. Every insert is recorded as a new
+// Artwork Revision on the same transaction.
+//
+// Artworks using an already stored rei are reported at once before any
+// insert, as an ErrConflict error holding the failures by ImportRecord row.
+//
+// records: The ImportRecords to insert, their Artworks ID and Version are set
+// once inserted.
+// author: Who performs the import.
+// dryRun: true to only check the conflicts, nothing is inserted.
+//
+// Returns an error if any.
+func (c *Client) ImportArtworks(ctx context.Context, records []ImportRecord, author string, dryRun bool) error {
+	defer observeQuery("ImportArtworks", time.Now())
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to begin the Artwork transaction. Err: %w", err)
+	}
+	defer tx.Rollback()
+
+	var failures []FieldError
+
+	for start := 0; start < len(records); start += importBatchSize {
+		batch := records[start:minInt(start+importBatchSize, len(records))]
+
+		existing, err := findReis(ctx, tx, batch)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range batch {
+			if ID, ok := existing[reiKey(record.Artwork.Rei)]; ok {
+				failures = append(failures, FieldError{
+					Row:     record.Row,
+					Field:   "rei",
+					Message: fmt.Sprintf("The rei %s is already used by the Artwork with id: %d", record.Artwork.Rei, ID),
+				})
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return &Error{
+			Kind:   ErrConflict,
+			Detail: fmt.Sprintf("The import has %d rei already in use", len(failures)),
+			Fields: failures,
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	for start := 0; start < len(records); start += importBatchSize {
+		batch := records[start:minInt(start+importBatchSize, len(records))]
+
+		var values []interface{}
+		rows := make([]string, len(batch))
+		for i, record := range batch {
+			rows[i] = "(" + placeholders(len(insertColumns)) + ")"
+			values = append(values, columnValues(insertColumns, record.Artwork)...)
+		}
+
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO artworks ("+columnNames(insertColumns)+") VALUES "+strings.Join(rows, ", "), values...)
+		if isDuplicate(err) {
+			return newError(ErrConflict, "The import conflicts with the stored Artworks")
+		}
+		if err != nil {
+			return fmt.Errorf("Unable to execute the Artworks import INSERT statement. Err: %w", err)
+		}
+
+		// The rei is unique, so it identifies the inserted rows no matter how
+		// the database assigned their ids.
+		inserted, err := findReis(ctx, tx, batch)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range batch {
+			record.Artwork.ID = inserted[reiKey(record.Artwork.Rei)]
+			record.Artwork.Version = 1
+
+			if err := recordRevision(ctx, tx, "INSERT", author, &Artwork{}, record.Artwork); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Unable to commit the Artwork transaction. Err: %w", err)
+	}
+
+	return nil
+}
+
+// findReis returns the ids of the stored Artworks, deleted ones included,
+// using any of the given ImportRecords rei, by reiKey.
+func findReis(ctx context.Context, q queryer, records []ImportRecord) (map[string]int, error) {
+	reis := make([]interface{}, len(records))
+	for i, record := range records {
+		reis[i] = record.Artwork.Rei
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT id, rei FROM artworks WHERE rei IN ("+placeholders(len(reis))+")", reis...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artworks table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	found := map[string]int{}
+	for rows.Next() {
+		var ID int
+		var rei string
+		if err := rows.Scan(&ID, &rei); err != nil {
+			return nil, fmt.Errorf("Unable to map an Artwork data row. Err: %w", err)
+		}
+		found[reiKey(rei)] = ID
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Artworks data. Err %w", err)
+	}
+
+	return found, nil
+}
+
+// headerColumns returns the column of every CSV header, headers should be
+// either mapped or Artwork field names (case insensitive).
+func headerColumns(header []string, mapping map[string]string) ([]column, error) {
+	cols := make([]column, len(header))
+	seen := map[string]bool{}
+	var failures []FieldError
+
+	for i, name := range header {
+		field, ok := mapping[normalizeHeader(name)]
+		if !ok {
+			field = normalizeHeader(name)
+		}
+
+		col, ok := importColumn(field)
+		if !ok {
+			failures = append(failures, FieldError{Field: name, Message: "The header is not an Artwork field, it should be mapped"})
+			continue
+		}
+
+		if seen[col.name] {
+			failures = append(failures, FieldError{Field: name, Message: fmt.Sprintf("The field %s is already on another header", col.name)})
+		}
+		seen[col.name] = true
+		cols[i] = col
+	}
+
+	if len(failures) > 0 {
+		return nil, &Error{Kind: ErrValidation, Detail: "The import header is not valid", Fields: failures}
+	}
+
+	return cols, nil
+}
+
+// importColumn returns the column of an importable Artwork JSON field name.
+func importColumn(name string) (column, bool) {
+	for _, col := range importColumns {
+		if col.name == name {
+			return col, true
+		}
+	}
+
+	return column{}, false
+}
+
+// normalizeHeader returns a CSV header in lower case without surrounding
+// spaces, so headers are matched no matter how the spreadsheet wrote them.
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// reiKey returns the rei compared case insensitively, as the artworks table
+// collation does.
+func reiKey(rei string) string {
+	return strings.ToLower(rei)
+}
+
+// isBlank tells whether every value of a CSV row is blank.
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// minInt returns the lowest of two ints.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
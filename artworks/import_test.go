@@ -0,0 +1,219 @@
+package artworks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParseImport(t *testing.T) {
+	opts, err := ParseImportOptions(url.Values{"delimiter": {";"}, "map": {"Título:tit", "Autor:aut"}})
+	if err != nil {
+		t.Errorf("ParseImportOptions returned a non expected error. Err: %s", err)
+		return
+	}
+
+	records, err := ParseImport(strings.NewReader("\xef\xbb\xbfREI;Título;Autor\n"+
+		"#F423432;Retrato de dama;Anónimo\n"+
+		";;\n"+
+		" #F423433 ;Puerto de Mahón;\n"), opts)
+	if err != nil {
+		t.Errorf("ParseImport returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if len(records) != 2 || records[0].Artwork.Tit != "Retrato de dama" || records[0].Artwork.Aut != "Anónimo" ||
+		records[1].Artwork.Rei != "#F423433" {
+		t.Errorf("The imported Artworks don't match the CSV rows. Got: %+v", records)
+		return
+	}
+
+	if records[0].Row != 1 || records[1].Row != 3 {
+		t.Errorf("The imported rows don't match the CSV rows Got: %d, %d Expected: 1, 3", records[0].Row, records[1].Row)
+	}
+
+	tests := []struct {
+		csv    string
+		fields []FieldError
+	}{
+		{
+			csv:    "rei,titulo\n#F423432,Retrato de dama\n",
+			fields: []FieldError{{Field: "titulo"}},
+		},
+		{
+			csv:    "rei,est\n#F423432,Bueno\n,Malo\n#F423432,Bueno\n#F4234321234,Bueno\n",
+			fields: []FieldError{{Row: 2, Field: "rei"}, {Row: 3, Field: "rei"}, {Row: 4, Field: "rei"}},
+		},
+		{
+			csv:    "rei\n#F423432\n#f423432\n",
+			fields: []FieldError{{Row: 2, Field: "rei"}},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseImport(strings.NewReader(test.csv), &ImportOptions{Delimiter: ','})
+
+		var artworksErr *Error
+		if !errors.Is(err, ErrValidation) || !errors.As(err, &artworksErr) {
+			t.Errorf("ParseImport should return a validation error for %q. Got: %v", test.csv, err)
+			continue
+		}
+
+		if len(artworksErr.Fields) != len(test.fields) {
+			t.Errorf("The import failures don't match for %q Got: %+v Expected: %+v", test.csv, artworksErr.Fields, test.fields)
+			continue
+		}
+
+		for i, field := range test.fields {
+			if artworksErr.Fields[i].Row != field.Row || artworksErr.Fields[i].Field != field.Field {
+				t.Errorf("The import failure don't match for %q Got: %+v Expected: %+v", test.csv, artworksErr.Fields[i], field)
+			}
+		}
+	}
+
+	for _, query := range []url.Values{{"delimiter": {"|"}}, {"map": {"Título"}}, {"map": {"Alta:created_at"}}, {"dry_run": {"si"}}} {
+		if _, err := ParseImportOptions(query); err == nil {
+			t.Errorf("The import options %v should not be valid", query)
+		}
+	}
+}
+
+func TestImportArtworks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	records := []ImportRecord{
+		{Row: 1, Artwork: &Artwork{Rei: "#F423432", CreatedAt: 1489140633}},
+		{Row: 2, Artwork: &Artwork{Rei: "#F423433", CreatedAt: 1489140633}},
+	}
+
+	args := append(columnArgs(insertColumns, records[0].Artwork), columnArgs(insertColumns, records[1].Artwork)...)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, rei FROM artworks WHERE rei IN \\(\\?,\\?\\)").
+		WithArgs("#F423432", "#F423433").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rei"}))
+	mock.ExpectExec("INSERT INTO artworks \\(.+\\) VALUES \\(.+\\), \\(.+\\)").
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(3, 2))
+	mock.ExpectQuery("SELECT id, rei FROM artworks WHERE rei IN \\(\\?,\\?\\)").
+		WithArgs("#F423432", "#F423433").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rei"}).AddRow(3, "#F423432").AddRow(4, "#F423433"))
+	for _, ID := range []int64{3, 4} {
+		mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+			WithArgs(ID).
+			WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO artwork_revisions").
+			WithArgs(ID, 1, "INSERT", "jcleira", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(ID, 1))
+	}
+	mock.ExpectCommit()
+
+	if err := artworksClient.ImportArtworks(context.Background(), records, "jcleira", false); err != nil {
+		t.Errorf("ImportArtworks returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if records[0].Artwork.ID != 3 || records[1].Artwork.ID != 4 || records[1].Artwork.Version != 1 {
+		t.Errorf("The imported Artworks ids don't match Got: %+v, %+v", records[0].Artwork, records[1].Artwork)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestImportArtworksConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	// The rows are the CSV ones, the row 2 was a blank one.
+	records := []ImportRecord{{Row: 1, Artwork: &Artwork{Rei: "#F423432"}}, {Row: 3, Artwork: &Artwork{Rei: "#eu82ree"}}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, rei FROM artworks WHERE rei IN").
+		WithArgs("#F423432", "#eu82ree").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rei"}).AddRow(1, "#EU82REE"))
+	mock.ExpectRollback()
+
+	err = artworksClient.ImportArtworks(context.Background(), records, "jcleira", true)
+
+	var artworksErr *Error
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &artworksErr) || len(artworksErr.Fields) != 1 || artworksErr.Fields[0].Row != 3 {
+		t.Errorf("ImportArtworks should return a conflict on the row 3. Got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestImportArtworksHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/import", ProblemHandler(ImportArtworksHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		params     string
+		csv        string
+		statusCode int
+		result     ImportResult
+	}{
+		{params: "?dry_run=true", csv: "rei,tit\n#F423432,Retrato de dama\n", statusCode: http.StatusOK, result: ImportResult{DryRun: true, Total: 1}},
+		{params: "", csv: "rei,tit\n#F423432,Retrato de dama\n#F423433,\n", statusCode: http.StatusCreated, result: ImportResult{Total: 2, IDs: []int{2, 3}}},
+		{params: "?dry_run=true", csv: "rei,tit\n#EU82REE,Retrato de dama\n", statusCode: http.StatusConflict},
+		{params: "?dry_run=true", csv: "rei,tit\n,Retrato de dama\n", statusCode: http.StatusUnprocessableEntity},
+		{params: "?delimiter=|", csv: "rei\n#F423432\n", statusCode: http.StatusBadRequest},
+		{params: "", csv: "rei,tit\n\"#F423432,Retrato de dama\n", statusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Post(server.URL+"/artworks/import"+test.params, "text/csv", strings.NewReader(test.csv))
+		if err != nil {
+			t.Errorf("Unable to perform ImportArtworks request. Err: %s", err)
+			return
+		}
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %q Got: %d Expected: %d", test.csv, resp.StatusCode, test.statusCode)
+			resp.Body.Close()
+			continue
+		}
+
+		if test.statusCode < http.StatusBadRequest {
+			var result ImportResult
+			json.NewDecoder(resp.Body).Decode(&result)
+
+			if result.DryRun != test.result.DryRun || result.Total != test.result.Total || len(result.IDs) != len(test.result.IDs) {
+				t.Errorf("The import result don't match for %q Got: %+v Expected: %+v", test.csv, result, test.result)
+			}
+		}
+		resp.Body.Close()
+	}
+}
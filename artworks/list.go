@@ -0,0 +1,249 @@
+package artworks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultListLimit is the amount of Artworks returned on a listing page
+	// when no limit has been requested.
+	DefaultListLimit = 100
+
+	// MaxListLimit is the maximum amount of Artworks that could be requested
+	// on a single listing page.
+	MaxListLimit = 1000
+)
+
+// sortColumns are the Artworks columns that could be used to sort a listing,
+// the value tells whether the column is numeric.
+var sortColumns = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"aut":        false,
+	"fec":        false,
+	"tit":        false,
+}
+
+// filterColumns are the Artworks columns that could be used to filter a
+// listing, filters are exact matches.
+var filterColumns = []string{"aut", "ubi", "tip", "tec", "est", "pro"}
+
+// ListOptions contains the pagination, sorting and filtering settings for an
+// Artworks listing.
+//
+// Pagination could be done either by Cursor or by Offset, but not both at
+// the same time. Cursors are opaque strings returned on ArtworksPage.
+//
+// Deleted lists the Artworks on the trash instead of the live ones, Public
+// only lists the publicable ones.
+type ListOptions struct {
+	Limit   int
+	Offset  int
+	Cursor  string
+	Sort    string
+	Desc    bool
+	Filters map[string]string
+	Deleted bool
+	Public  bool
+}
+
+// ArtworksPage is a single page of an Artworks listing.
+//
+// Total is the amount of Artworks matching the listing filters, NextCursor
+// would be empty on the last page.
+type ArtworksPage struct {
+	Artworks   []Artwork
+	Total      int
+	NextCursor string
+}
+
+// cursor is the decoded representation of a ListOptions Cursor, it holds the
+// last Artwork sort value and ID seen on the previous page.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// NewListOptions returns the ListOptions with the default settings, sorted by
+// id and with the default page limit.
+func NewListOptions() *ListOptions {
+	return &ListOptions{
+		Limit:   DefaultListLimit,
+		Sort:    "id",
+		Filters: map[string]string{},
+	}
+}
+
+// ParseListOptions builds a ListOptions from the given URL query values.
+//
+// Available params:
+//
+// 'limit': Amount of Artworks per page, up to MaxListLimit.
+// 'cursor': The cursor returned by a previous page.
+// 'offset': Amount of Artworks to skip, it can't be used along with cursor.
+// 'sort': One of id, created_at, aut, fec or tit, '-' prefixed for descending.
+// 'aut', 'ubi', 'tip', 'tec', 'est', 'pro': Exact match filters.
+//
+// query: The URL query values.
+//
+// Returns:
+// The parsed ListOptions.
+// An error if any of the params is not valid.
+func ParseListOptions(query url.Values) (*ListOptions, error) {
+	opts := NewListOptions()
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxListLimit {
+			return nil, fmt.Errorf("The limit param should be a number between 1 and %d", MaxListLimit)
+		}
+		opts.Limit = value
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("The offset param should be a positive number")
+		}
+		opts.Offset = value
+	}
+
+	opts.Cursor = query.Get("cursor")
+	if opts.Cursor != "" && opts.Offset > 0 {
+		return nil, fmt.Errorf("The cursor and offset params can't be used at the same time")
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		opts.Desc = strings.HasPrefix(sort, "-")
+		opts.Sort = strings.TrimPrefix(sort, "-")
+		if _, ok := sortColumns[opts.Sort]; !ok {
+			return nil, fmt.Errorf("The sort param should be one of id, created_at, aut, fec or tit")
+		}
+	}
+
+	if opts.Cursor != "" {
+		if c, err := decodeCursor(opts.Cursor); err != nil || c.Sort != opts.Sort {
+			return nil, fmt.Errorf("The cursor param is not valid for the requested sort")
+		}
+	}
+
+	for _, column := range filterColumns {
+		if value := query.Get(column); value != "" {
+			opts.Filters[column] = value
+		}
+	}
+
+	return opts, nil
+}
+
+// where returns the SQL WHERE clause (with a leading space) and its
+// arguments for the ListOptions filters, deletion state and visibility.
+func (opts *ListOptions) where() (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	if opts.Deleted {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+
+	if opts.Public {
+		conditions = append(conditions, "publicable=1")
+	}
+
+	var args []interface{}
+
+	for _, column := range filterColumns {
+		if value, ok := opts.Filters[column]; ok {
+			conditions = append(conditions, column+"=?")
+			args = append(args, value)
+		}
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// after returns the SQL condition and its arguments to fetch the Artworks
+// following the ListOptions Cursor, it's empty when there is no Cursor.
+func (opts *ListOptions) after() (string, []interface{}, error) {
+	if opts.Cursor == "" {
+		return "", nil, nil
+	}
+
+	c, err := decodeCursor(opts.Cursor)
+	if err != nil || c.Sort != opts.Sort {
+		return "", nil, fmt.Errorf("The cursor param is not valid for the requested sort")
+	}
+
+	operator := ">"
+	if opts.Desc {
+		operator = "<"
+	}
+
+	if opts.Sort == "id" {
+		return "id" + operator + "?", []interface{}{c.ID}, nil
+	}
+
+	var value interface{} = c.Value
+	if sortColumns[opts.Sort] {
+		if value, err = strconv.ParseInt(c.Value, 10, 64); err != nil {
+			return "", nil, fmt.Errorf("The cursor param is not valid for the requested sort")
+		}
+	}
+
+	return fmt.Sprintf("(%[1]s%[2]s? OR (%[1]s=? AND id%[2]s?))", opts.Sort, operator),
+		[]interface{}{value, value, c.ID}, nil
+}
+
+// orderBy returns the SQL ORDER BY clause for the ListOptions sort, Artworks
+// id is always used to break ties so cursors are stable.
+func (opts *ListOptions) orderBy() string {
+	direction := "ASC"
+	if opts.Desc {
+		direction = "DESC"
+	}
+
+	if opts.Sort == "id" {
+		return " ORDER BY id " + direction
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", opts.Sort, direction, direction)
+}
+
+// nextCursor returns the Cursor pointing after the given Artwork for the
+// ListOptions sort.
+func (opts *ListOptions) nextCursor(artwork *Artwork) string {
+	c := cursor{Sort: opts.Sort, ID: artwork.ID}
+
+	switch opts.Sort {
+	case "created_at":
+		c.Value = strconv.FormatInt(artwork.CreatedAt, 10)
+	case "aut":
+		c.Value = artwork.Aut
+	case "fec":
+		c.Value = artwork.Fec
+	case "tit":
+		c.Value = artwork.Tit
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor decodes an opaque cursor string.
+func decodeCursor(encoded string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
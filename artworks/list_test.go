@@ -0,0 +1,120 @@
+package artworks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParseListOptions(t *testing.T) {
+	validCursor := NewListOptions().nextCursor(&Artwork{ID: 2})
+
+	tests := []struct {
+		query         string
+		expectedError bool
+	}{
+		{query: "", expectedError: false},
+		{query: "limit=10&sort=-created_at&aut=Goya", expectedError: false},
+		{query: fmt.Sprint("cursor=", validCursor), expectedError: false},
+		{query: "limit=0", expectedError: true},
+		{query: "limit=foo", expectedError: true},
+		{query: "offset=-1", expectedError: true},
+		{query: "sort=foo", expectedError: true},
+		{query: fmt.Sprint("offset=10&cursor=", validCursor), expectedError: true},
+		{query: fmt.Sprint("sort=tit&cursor=", validCursor), expectedError: true},
+		{query: "cursor=foo", expectedError: true},
+	}
+
+	for _, test := range tests {
+		query, _ := url.ParseQuery(test.query)
+
+		_, err := ParseListOptions(query)
+		if (err != nil) != test.expectedError {
+			t.Errorf("The returned error from ParseListOptions don't match the test case for %q. Got: %v", test.query, err)
+		}
+	}
+}
+
+func TestQueryArtworks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	opts := NewListOptions()
+	opts.Limit = 1
+	opts.Filters["est"] = "Bueno"
+
+	mock.ExpectQuery("SELECT COUNT(.+) FROM artworks WHERE deleted_at IS NULL AND est=?").
+		WithArgs("Bueno").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE deleted_at IS NULL AND est=\\? ORDER BY id ASC LIMIT").
+		WithArgs("Bueno", 2, 0).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631, Est: "Bueno"})...).
+			AddRow(columnArgs(columns, &Artwork{ID: 2, Rei: "#F423432", CreatedAt: 1489140633, Est: "Bueno"})...))
+
+	page, err := artworksClient.QueryArtworks(context.Background(), opts)
+	if err != nil {
+		t.Errorf("QueryArtworks returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if page.Total != 2 || len(page.Artworks) != 1 || page.NextCursor == "" {
+		t.Errorf("The returned page from QueryArtworks don't match the expected. Got: %+v", page)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestGetArtworksHandlerPagination(t *testing.T) {
+	server := httptest.NewServer(GetArtworksHandler(&FakeClient{}))
+	defer server.Close()
+
+	tests := []struct {
+		params     string
+		statusCode int
+		hasNext    bool
+	}{
+		{params: "", statusCode: http.StatusOK, hasNext: false},
+		{params: "?limit=1", statusCode: http.StatusOK, hasNext: true},
+		{params: "?limit=foo", statusCode: http.StatusBadRequest, hasNext: false},
+	}
+
+	for _, test := range tests {
+		r, err := http.Get(fmt.Sprint(server.URL, test.params))
+		if err != nil {
+			t.Errorf("Unable to perform GetArtworks request. Err: %s", err)
+			return
+		}
+		r.Body.Close()
+
+		if r.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match Got: %d Expected: %d", r.StatusCode, test.statusCode)
+			return
+		}
+
+		if test.statusCode == http.StatusOK && r.Header.Get("X-Total-Count") != "2" {
+			t.Errorf("The X-Total-Count header don't match Got: %s Expected: 2", r.Header.Get("X-Total-Count"))
+		}
+
+		if hasNext := r.Header.Get("Link") != ""; hasNext != test.hasNext {
+			t.Errorf("The Link header presence don't match Got: %t Expected: %t", hasNext, test.hasNext)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package artworks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "artworks_http_requests_total",
+		Help: "HTTP requests served, by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "artworks_http_request_duration_seconds",
+		Help:    "HTTP requests latency, by route template, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "artworks_db_query_duration_seconds",
+		Help:    "Database work duration, by Client method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpDuration, dbQueryDuration)
+}
+
+// ConfigureMetricsHandlers is meant to be called by the server.go main
+// routine with the admin router, it serves the Prometheus metrics on
+// /metrics. The admin listener isn't exposed along with the public API, so
+// the metrics are scraped without authentication.
+//
+// The public API routes are instrumented by MetricsMiddleware.
+//
+// r: The admin HTTP server *mux.Router to be configured.
+// artworksClient: The Client whose database is reported.
+//
+// Returns nothing.
+func ConfigureMetricsHandlers(r *mux.Router, artworksClient *Client) {
+	prometheus.MustRegister(&clientCollector{client: artworksClient})
+
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+}
+
+// MetricsMiddleware records the requests count and latency by the matched
+// route template, as /artworks/{id:[0-9]+}, so the Artwork IDs don't blow up
+// the metrics cardinality.
+//
+// next: The http.Handler to instrument.
+//
+// Returns the instrumented http.Handler.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		status := strconv.Itoa(sw.status)
+		httpRequests.WithLabelValues(route, r.Method, status).Inc()
+		httpDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter records the response status, it keeps the http.Flusher so
+// the streamed listings are still flushed.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.status, sw.wroteHeader = status, true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(p)
+}
+
+func (sw *statusWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// observeQuery records a Client method database work duration.
+func observeQuery(method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+var (
+	dbConnectionsDesc = prometheus.NewDesc("artworks_db_connections",
+		"Database pool connections, by state.", []string{"state"}, nil)
+	dbMaxOpenConnectionsDesc = prometheus.NewDesc("artworks_db_max_open_connections",
+		"Database pool maximum open connections.", nil, nil)
+	dbWaitCountDesc = prometheus.NewDesc("artworks_db_wait_count_total",
+		"Database connections waited for.", nil, nil)
+	dbWaitDurationDesc = prometheus.NewDesc("artworks_db_wait_duration_seconds_total",
+		"Time blocked waiting for database connections.", nil, nil)
+	artworksDesc = prometheus.NewDesc("artworks_artworks",
+		"Artworks on the catalogue, trashed ones excluded, by est (state).", []string{"est"}, nil)
+)
+
+// clientCollector reports the Client sql.DB pool stats and the Artworks
+// counts, they are read on every scrape.
+type clientCollector struct {
+	client *Client
+}
+
+func (cc *clientCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbConnectionsDesc
+	ch <- dbMaxOpenConnectionsDesc
+	ch <- dbWaitCountDesc
+	ch <- dbWaitDurationDesc
+	ch <- artworksDesc
+}
+
+func (cc *clientCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := cc.client.DB.Stats()
+
+	ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.InUse), "in_use")
+	ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.Idle), "idle")
+	ch <- prometheus.MustNewConstMetric(dbMaxOpenConnectionsDesc, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbWaitCountDesc, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbWaitDurationDesc, prometheus.CounterValue, stats.WaitDuration.Seconds())
+
+	counts, err := cc.client.CountArtworksByEst(context.Background())
+	if err != nil {
+		log.Printf("Unable to collect the Artworks metrics. Err: %s", err)
+		return
+	}
+
+	for est, count := range counts {
+		ch <- prometheus.MustNewConstMetric(artworksDesc, prometheus.GaugeValue, float64(count), est)
+	}
+}
+
+// CountArtworksByEst returns the amount of Artworks by est (state), the
+// trashed Artworks are not counted.
+//
+// ctx: The request context.
+//
+// Returns:
+// The Artworks count by est.
+// An error if any.
+func (c *Client) CountArtworksByEst(ctx context.Context) (map[string]int, error) {
+	ctx, cancel := c.withTimeout(ctx, "CountArtworksByEst")
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx,
+		"SELECT est, COUNT(*) FROM artworks WHERE deleted_at IS NULL GROUP BY est")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to count the Artworks. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			est   string
+			count int
+		)
+		if err := rows.Scan(&est, &count); err != nil {
+			return nil, fmt.Errorf("Unable to map an Artworks count data row. Err: %w", err)
+		}
+		counts[est] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Artworks count data. Err %w", err)
+	}
+
+	return counts, nil
+}
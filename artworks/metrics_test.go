@@ -0,0 +1,87 @@
+package artworks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(MetricsMiddleware)
+	r.HandleFunc("/metrics-test/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods("GET")
+
+	for _, url := range []string{"/metrics-test/1", "/metrics-test/3", "/metrics-test/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+	}
+
+	tests := []struct {
+		status   string
+		expected float64
+	}{
+		{status: "200", expected: 2},
+		{status: "404", expected: 1},
+	}
+
+	for _, test := range tests {
+		counter := httpRequests.WithLabelValues("/metrics-test/{id:[0-9]+}", "GET", test.status)
+		if got := testutil.ToFloat64(counter); got != test.expected {
+			t.Errorf("The requests count don't match for %s Got: %v Expected: %v", test.status, got, test.expected)
+		}
+	}
+}
+
+func TestClientCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT est, COUNT(.+) FROM artworks WHERE deleted_at IS NULL GROUP BY est").
+		WillReturnRows(sqlmock.NewRows([]string{"est", "count"}).AddRow("Bueno", 12).AddRow("Malo", 3))
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&clientCollector{client: &Client{DB: db}})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Errorf("Unable to gather the metrics. Err: %s", err)
+		return
+	}
+
+	artworks := map[string]float64{}
+	var pool bool
+	for _, family := range families {
+		switch family.GetName() {
+		case "artworks_artworks":
+			for _, metric := range family.GetMetric() {
+				artworks[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+			}
+		case "artworks_db_connections":
+			pool = true
+		}
+	}
+
+	if artworks["Bueno"] != 12 || artworks["Malo"] != 3 {
+		t.Errorf("The Artworks by est don't match Got: %v", artworks)
+	}
+
+	if !pool {
+		t.Errorf("The database pool stats are not collected")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
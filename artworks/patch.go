@@ -0,0 +1,169 @@
+package artworks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// MergePatchContentType is the RFC 7396 JSON Merge Patch media type.
+	MergePatchContentType = "application/merge-patch+json"
+
+	// JSONPatchContentType is the RFC 6902 JSON Patch media type.
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+// patchOperation is a single RFC 6902 JSON Patch operation.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParseMergePatch decodes a RFC 7396 JSON Merge Patch document for an
+// Artwork. As every Artwork field is a flat value, null members reset the
+// field to its empty value.
+//
+// data: The merge patch document.
+//
+// Returns:
+// An Artwork holding the patched values.
+// The patched Artwork JSON field names.
+// An error if the document is not valid, an ErrValidation one if it patches a
+// read-only field.
+func ParseMergePatch(data []byte) (*Artwork, []string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return nil, nil, fmt.Errorf("The merge patch should be a JSON object")
+	}
+
+	var patch Artwork
+	var fields []string
+
+	for name, value := range members {
+		col, ok := patchableColumn(name)
+		if !ok {
+			return nil, nil, newError(ErrValidation, "The field %s doesn't exist or can't be patched", name)
+		}
+
+		if err := setColumnValue(col, &patch, value); err != nil {
+			return nil, nil, err
+		}
+
+		fields = append(fields, name)
+	}
+
+	return &patch, fields, nil
+}
+
+// ParseJSONPatch decodes a RFC 6902 JSON Patch document for an Artwork and
+// applies it on top of the current Artwork state. The add, replace, remove,
+// copy, move and test operations are supported on the Artwork fields.
+//
+// data: The JSON patch document.
+// current: The current Artwork state, used by the test, copy and move
+// operations.
+//
+// Returns:
+// An Artwork holding the patched values.
+// The patched Artwork JSON field names.
+// An error if the document is not valid, an ErrConflict one if any test
+// operation fails.
+func ParseJSONPatch(data []byte, current *Artwork) (*Artwork, []string, error) {
+	var operations []patchOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, nil, fmt.Errorf("The JSON patch should be an array of operations")
+	}
+
+	patch := *current
+	patched := map[string]bool{}
+
+	for _, operation := range operations {
+		col, ok := patchableColumn(strings.TrimPrefix(operation.Path, "/"))
+		if !ok || !strings.HasPrefix(operation.Path, "/") {
+			return nil, nil, newError(ErrValidation, "The path %s doesn't exist or can't be patched", operation.Path)
+		}
+
+		switch operation.Op {
+		case "add", "replace":
+			if err := setColumnValue(col, &patch, operation.Value); err != nil {
+				return nil, nil, err
+			}
+		case "remove":
+			if err := setColumnValue(col, &patch, nil); err != nil {
+				return nil, nil, err
+			}
+		case "copy", "move":
+			from, ok := patchableColumn(strings.TrimPrefix(operation.From, "/"))
+			if !ok || !strings.HasPrefix(operation.From, "/") {
+				return nil, nil, newError(ErrValidation, "The path %s doesn't exist or can't be patched", operation.From)
+			}
+
+			value, _ := json.Marshal(from.field(&patch))
+			if err := setColumnValue(col, &patch, value); err != nil {
+				return nil, nil, err
+			}
+
+			if operation.Op == "move" {
+				setColumnValue(from, &patch, nil)
+				patched[from.name] = true
+			}
+		case "test":
+			expected := reflect.New(reflect.TypeOf(col.field(&patch)).Elem())
+			if err := json.Unmarshal(operation.Value, expected.Interface()); err != nil {
+				return nil, nil, fmt.Errorf("The test value for %s is not valid", operation.Path)
+			}
+
+			actual := reflect.ValueOf(col.field(&patch)).Elem().Interface()
+			if !reflect.DeepEqual(expected.Elem().Interface(), actual) {
+				return nil, nil, newError(ErrConflict, "The test operation on %s failed", operation.Path)
+			}
+			continue
+		default:
+			return nil, nil, fmt.Errorf("The JSON patch operation %s is not valid", operation.Op)
+		}
+
+		patched[col.name] = true
+	}
+
+	var fields []string
+	for _, col := range updateColumns {
+		if patched[col.name] {
+			fields = append(fields, col.name)
+		}
+	}
+
+	return &patch, fields, nil
+}
+
+// patchableColumn returns the column of a patchable Artwork JSON field name.
+func patchableColumn(name string) (column, bool) {
+	for _, col := range updateColumns {
+		if col.name == name {
+			return col, true
+		}
+	}
+
+	return column{}, false
+}
+
+// setColumnValue decodes a JSON value into the column Artwork field, a
+// missing or null value resets the field to its empty value.
+func setColumnValue(col column, artwork *Artwork, value json.RawMessage) error {
+	field := reflect.ValueOf(col.field(artwork)).Elem()
+
+	if len(value) == 0 || bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if err := json.Unmarshal(value, col.field(artwork)); err != nil {
+		return newError(ErrValidation, "The value for the field %s is not valid", col.name)
+	}
+
+	return nil
+}
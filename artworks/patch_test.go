@@ -0,0 +1,138 @@
+package artworks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	tests := []struct {
+		patch          string
+		expectedFields []string
+		expectedError  bool
+	}{
+		{patch: `{"est": "Regular"}`, expectedFields: []string{"est"}},
+		{patch: `{"ubi": null}`, expectedFields: []string{"ubi"}},
+		{patch: `{}`, expectedFields: nil},
+		{patch: `{"id": 2}`, expectedError: true},
+		{patch: `{"created_at": 1489140631}`, expectedError: true},
+		{patch: `{"est": 1}`, expectedError: true},
+		{patch: `[]`, expectedError: true},
+	}
+
+	for _, test := range tests {
+		_, fields, err := ParseMergePatch([]byte(test.patch))
+		if (err != nil) != test.expectedError {
+			t.Errorf("The returned error from ParseMergePatch don't match the test case for %s. Got: %v", test.patch, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(fields, test.expectedFields) {
+			t.Errorf("The returned fields don't match the expected. Got: %v Expected: %v", fields, test.expectedFields)
+		}
+	}
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	current := &Artwork{ID: 1, Est: "Bueno", Ubi: "Almacén"}
+
+	tests := []struct {
+		patch           string
+		expectedArtwork *Artwork
+		expectedFields  []string
+		expectedError   bool
+	}{
+		{
+			patch:           `[{"op": "test", "path": "/est", "value": "Bueno"}, {"op": "replace", "path": "/est", "value": "Regular"}]`,
+			expectedArtwork: &Artwork{ID: 1, Est: "Regular", Ubi: "Almacén"},
+			expectedFields:  []string{"est"},
+		},
+		{
+			patch:           `[{"op": "move", "from": "/ubi", "path": "/lug"}]`,
+			expectedArtwork: &Artwork{ID: 1, Est: "Bueno", Lug: "Almacén"},
+			expectedFields:  []string{"ubi", "lug"},
+		},
+		{
+			patch:         `[{"op": "test", "path": "/est", "value": "Malo"}]`,
+			expectedError: true,
+		},
+		{
+			patch:         `[{"op": "replace", "path": "/id", "value": 2}]`,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		artwork, fields, err := ParseJSONPatch([]byte(test.patch), current)
+		if (err != nil) != test.expectedError {
+			t.Errorf("The returned error from ParseJSONPatch don't match the test case for %s. Got: %v", test.patch, err)
+			continue
+		}
+
+		if test.expectedError {
+			continue
+		}
+
+		if !reflect.DeepEqual(artwork, test.expectedArtwork) || !reflect.DeepEqual(fields, test.expectedFields) {
+			t.Errorf("The returned patch don't match the expected. Got: %v %v Expected: %v %v", artwork, fields, test.expectedArtwork, test.expectedFields)
+		}
+	}
+}
+
+func TestPatchArtworkHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}", PatchArtworkHandler(&FakeClient{}))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		contentType string
+		body        string
+		statusCode  int
+		expectedEst string
+	}{
+		{contentType: MergePatchContentType, body: `{"est": "Regular"}`, statusCode: http.StatusOK, expectedEst: "Regular"},
+		{contentType: JSONPatchContentType, body: `[{"op": "add", "path": "/est", "value": "Malo"}]`, statusCode: http.StatusOK, expectedEst: "Malo"},
+		{contentType: MergePatchContentType, body: `{"id": 2}`, statusCode: http.StatusUnprocessableEntity},
+		{contentType: MergePatchContentType, body: `["est"]`, statusCode: http.StatusBadRequest},
+		{contentType: "text/plain", body: `est=Malo`, statusCode: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodPatch, fmt.Sprint(server.URL, "/artworks/1"), bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Errorf("Unable to perform PatchArtwork request. Err: %s", err)
+			return
+		}
+		req.Header.Set("Content-Type", test.contentType)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("Unable to perform PatchArtwork request. Err: %s", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response status code don't match the expected. Got: %d Expected: %d", resp.StatusCode, test.statusCode)
+			return
+		}
+
+		if test.statusCode == http.StatusOK {
+			var artwork Artwork
+			json.NewDecoder(resp.Body).Decode(&artwork)
+
+			if artwork.Est != test.expectedEst {
+				t.Errorf("The patched Artwork est don't match the expected. Got: %s Expected: %s", artwork.Est, test.expectedEst)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package artworks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultPublicFields are the Artwork fields (by JSON field name) served by
+// default on the public API, only for the publicable Artworks. The location,
+// provenance, acquisition, owner and valuation fields are never public by
+// default, as they would help stealing the Artworks.
+//
+// The served fields are given to ConfigurePublicHandlers, see
+// ParsePublicFields.
+var DefaultPublicFields = []string{
+	"id", "rei", "nom", "tit", "aut", "fec", "lug", "ico", "tip", "tec",
+	"sop", "mat", "tin", "dim", "hue", "ins", "des",
+}
+
+// internalFields are the Artwork fields that are never public, they are
+// only meaningful to the API.
+var internalFields = []string{"publicable", "deleted_at", "version"}
+
+// ParsePublicFields validates a comma separated list of Artwork fields, to
+// be served on the public API.
+//
+// value: The comma separated JSON field names, as "id,rei,tit".
+//
+// Returns:
+// The field names.
+// An error if any of them is not an Artwork field or can't be public.
+func ParsePublicFields(value string) ([]string, error) {
+	var fields []string
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !isColumn(name) || contains(internalFields, name) {
+			return nil, fmt.Errorf("The field %s can't be public", name)
+		}
+
+		fields = append(fields, name)
+	}
+
+	if !contains(fields, "id") {
+		return nil, fmt.Errorf("The public fields should include the id")
+	}
+
+	return fields, nil
+}
+
+// publicArtwork returns the public projection of the Artworks, holding only
+// the given public fields.
+func publicArtwork(fields []string) projection {
+	return func(artwork *Artwork) interface{} {
+		public := make(map[string]interface{}, len(fields))
+
+		for _, col := range columns {
+			if contains(fields, col.name) {
+				public[col.name] = col.field(artwork)
+			}
+		}
+
+		return public
+	}
+}
+
+// checkPublicOptions rejects the public listing filters and sorting on non
+// public fields, they would disclose them.
+//
+// fields: The public fields.
+// opts: The listing settings.
+//
+// Returns an error if any of them is on a non public field.
+func checkPublicOptions(fields []string, opts *ListOptions) error {
+	for field := range opts.Filters {
+		if !contains(fields, field) {
+			return fmt.Errorf("The %s field can't be used as a filter", field)
+		}
+	}
+
+	if !contains(fields, opts.Sort) {
+		return fmt.Errorf("The Artworks can't be sorted by %s", opts.Sort)
+	}
+
+	return nil
+}
+
+// isColumn tells whether the given name is an artworks table column.
+func isColumn(name string) bool {
+	for _, col := range columns {
+		if col.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains tells whether the given names hold name.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package artworks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParsePublicFields(t *testing.T) {
+	tests := []struct {
+		value         string
+		fields        []string
+		expectedError bool
+	}{
+		{value: "id, rei,tit", fields: []string{"id", "rei", "tit"}},
+		{value: "id,ubi", fields: []string{"id", "ubi"}},
+		{value: "rei,tit", expectedError: true},
+		{value: "id,publicable", expectedError: true},
+		{value: "id,titulo", expectedError: true},
+	}
+
+	for _, test := range tests {
+		fields, err := ParsePublicFields(test.value)
+		if (err != nil) != test.expectedError {
+			t.Errorf("ParsePublicFields returned a non expected error for %q. Err: %v", test.value, err)
+			continue
+		}
+
+		if strings.Join(fields, ",") != strings.Join(test.fields, ",") {
+			t.Errorf("The public fields don't match for %q Got: %v Expected: %v", test.value, fields, test.fields)
+		}
+	}
+}
+
+func TestQueryPublicArtworks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	opts := NewListOptions()
+	opts.Public = true
+
+	mock.ExpectQuery("SELECT COUNT(.+) FROM artworks WHERE deleted_at IS NULL AND publicable=1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE deleted_at IS NULL AND publicable=1 ORDER BY id ASC").
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")))
+
+	if _, err := artworksClient.QueryArtworksPage(context.Background(), opts); err != nil {
+		t.Errorf("QueryArtworksPage returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestPublicArtworksHandlers(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/public/artworks", ProblemHandler(GetPublicArtworksHandler(&FakeClient{}, DefaultPublicFields))).Methods("GET")
+	r.Handle("/public/artworks/{id:[0-9]+}", ProblemHandler(GetPublicArtworkHandler(&FakeClient{}, DefaultPublicFields))).Methods("GET")
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		url        string
+		statusCode int
+		total      string
+	}{
+		{url: "/public/artworks", statusCode: http.StatusOK, total: "1"},
+		{url: "/public/artworks?aut=Anónimo", statusCode: http.StatusOK, total: "1"},
+		{url: "/public/artworks?ubi=Sala%203", statusCode: http.StatusBadRequest},
+		{url: "/public/artworks?sort=created_at", statusCode: http.StatusBadRequest},
+		{url: "/public/artworks/1", statusCode: http.StatusOK},
+		{url: "/public/artworks/2", statusCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + test.url)
+		if err != nil {
+			t.Errorf("Unable to perform the %s request. Err: %s", test.url, err)
+			return
+		}
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.url, resp.StatusCode, test.statusCode)
+			resp.Body.Close()
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			continue
+		}
+
+		var artworks []map[string]interface{}
+		if test.total != "" {
+			if resp.Header.Get("X-Total-Count") != test.total {
+				t.Errorf("The X-Total-Count header don't match for %s Got: %s Expected: %s", test.url, resp.Header.Get("X-Total-Count"), test.total)
+			}
+			json.NewDecoder(resp.Body).Decode(&artworks)
+		} else {
+			var artwork map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&artwork)
+			artworks = append(artworks, artwork)
+		}
+		resp.Body.Close()
+
+		for _, artwork := range artworks {
+			if len(artwork) != len(DefaultPublicFields) || artwork["rei"] != "#EU82REE" {
+				t.Errorf("The public Artwork fields don't match for %s Got: %v", test.url, artwork)
+			}
+
+			for _, field := range []string{"ubi", "vap", "pro", "prp", "publicable", "version"} {
+				if _, ok := artwork[field]; ok {
+					t.Errorf("The %s field should not be public for %s Got: %v", field, test.url, artwork)
+				}
+			}
+		}
+	}
+}
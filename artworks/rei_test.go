@@ -0,0 +1,103 @@
+package artworks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestAddUpdateArtworkDuplicateRei(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	artwork := &Artwork{Rei: "#EU82REE", CreatedAt: 1489140633}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO artworks").ExpectExec().
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '#EU82REE' for key 'artworks_rei'"})
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE rei=\\? AND id<>\\?").
+		WithArgs("#EU82REE", 0).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631})...))
+	mock.ExpectRollback()
+
+	err = artworksClient.AddUpdateArtwork(context.Background(), "INSERT", artwork, "jcleira")
+
+	var artworksErr *Error
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &artworksErr) || artworksErr.ArtworkID != 1 {
+		t.Errorf("AddUpdateArtwork should return a conflict with the Artwork 1. Got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestGetArtworkByReiHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/by-rei/{rei}", ProblemHandler(GetArtworkByReiHandler(&FakeClient{})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		rei        string
+		statusCode int
+	}{
+		{rei: "%23EU82REE", statusCode: http.StatusOK},
+		{rei: "%23F423432", statusCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + "/artworks/by-rei/" + test.rei)
+		if err != nil {
+			t.Errorf("Unable to perform GetArtworkByRei request. Err: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.rei, resp.StatusCode, test.statusCode)
+		}
+	}
+}
+
+func TestAddArtworkHandlerDuplicateRei(t *testing.T) {
+	server := httptest.NewServer(ProblemHandler(AddArtworkHandler(&FakeClient{})))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", bytes.NewBufferString(`{"rei": "#EU82REE"}`))
+	if err != nil {
+		t.Errorf("Unable to perform AddArtwork request. Err: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("The response Status Code don't match Got: %d Expected: %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	var problem Problem
+	json.NewDecoder(resp.Body).Decode(&problem)
+
+	if problem.ArtworkID != 1 {
+		t.Errorf("The problem conflicting Artwork id don't match Got: %d Expected: 1", problem.ArtworkID)
+	}
+}
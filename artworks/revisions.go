@@ -0,0 +1,272 @@
+package artworks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Revision is a single change on an Artwork history, every INSERT, UPDATE,
+// DELETE, UNDELETE, PURGE or RESTORE performed on an Artwork records a new
+// Revision.
+//
+// Rev numbers are sequential per Artwork starting on 1. Changes holds the
+// field-level diff by Artwork JSON field name and Artwork is the Artwork state
+// right after the change (or right before it for purges), it's only returned
+// when fetching a single Revision.
+//
+// Example:
+// {
+//   rev: 2,
+//   artwork_id: 1,
+//   action: 'UPDATE',
+//   author: 'jcleira',
+//   created_at: 1489140631,
+//   changes: { est: { from: 'Bueno', to: 'Regular' } }
+// }
+type Revision struct {
+	Rev       int               `json:"rev"`
+	ArtworkID int               `json:"artwork_id"`
+	Action    string            `json:"action"`
+	Author    string            `json:"author"`
+	CreatedAt int64             `json:"created_at"`
+	Changes   map[string]Change `json:"changes"`
+	Artwork   *Artwork          `json:"artwork,omitempty"`
+}
+
+// Change is the previous and new value of a single Artwork field.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// GetRevisions returns the history of an Artwork, sorted from the oldest to
+// the newest Revision, without the Artwork states.
+//
+// artworkID: The Artwork id.
+//
+// Returns:
+// An array of Revisions.
+// An error otherwise.
+func (c *Client) GetRevisions(ctx context.Context, artworkID int) ([]Revision, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetRevisions")
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx,
+		"SELECT rev, artwork_id, action, author, created_at, changes FROM artwork_revisions "+
+			"WHERE artwork_id=? ORDER BY rev", artworkID)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artwork_revisions table. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	revisions := make([]Revision, 0)
+
+	for rows.Next() {
+		var revision Revision
+		var changes []byte
+
+		err := rows.Scan(&revision.Rev, &revision.ArtworkID, &revision.Action,
+			&revision.Author, &revision.CreatedAt, &changes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to map a Revision data row. Err: %w", err)
+		}
+
+		if err := json.Unmarshal(changes, &revision.Changes); err != nil {
+			return nil, fmt.Errorf("Unable to decode the Revision changes. Err: %w", err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on Revisions data. Err %w", err)
+	}
+
+	// Deleted Artworks keep their history, an Artwork without Revisions
+	// should still exist to be told apart from a missing one.
+	if len(revisions) == 0 {
+		artwork, err := findArtwork(ctx, c.DB, "SELECT "+selectColumns+" FROM artworks WHERE id=?", artworkID)
+		if err != nil {
+			return nil, err
+		}
+
+		if artwork == nil {
+			return nil, newError(ErrNotFound, "Unable to find an Artwork with id: %d", artworkID)
+		}
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns a single Revision of an Artwork, including the Artwork
+// state at that Revision.
+//
+// artworkID: The Artwork id.
+// rev: The Revision number.
+//
+// Returns:
+// A Revision.
+// An error otherwise.
+func (c *Client) GetRevision(ctx context.Context, artworkID int, rev int) (*Revision, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetRevision")
+	defer cancel()
+
+	var revision Revision
+	var changes, snapshot []byte
+
+	err := c.DB.QueryRowContext(ctx,
+		"SELECT rev, artwork_id, action, author, created_at, changes, snapshot FROM artwork_revisions "+
+			"WHERE artwork_id=? AND rev=?", artworkID, rev).
+		Scan(&revision.Rev, &revision.ArtworkID, &revision.Action,
+			&revision.Author, &revision.CreatedAt, &changes, &snapshot)
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, "Unable to find the Revision %d of the Artwork with id: %d", rev, artworkID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the artwork_revisions table. Err: %w", err)
+	}
+
+	if err := json.Unmarshal(changes, &revision.Changes); err != nil {
+		return nil, fmt.Errorf("Unable to decode the Revision changes. Err: %w", err)
+	}
+
+	if err := json.Unmarshal(snapshot, &revision.Artwork); err != nil {
+		return nil, fmt.Errorf("Unable to decode the Revision Artwork. Err: %w", err)
+	}
+
+	return &revision, nil
+}
+
+// RestoreRevision brings an Artwork back to the state it had on the given
+// Revision, its current deletion state is kept as trashing and untrashing
+// require their own permission. Purged Artworks can't be restored. The
+// restoration is recorded as a new RESTORE Revision.
+//
+// artworkID: The Artwork id.
+// rev: The Revision number to restore.
+// version: The expected Artwork Version, zero to skip the check.
+// author: Who performs the restoration.
+//
+// Returns:
+// The restored Artwork.
+// An error otherwise, ErrVersionMismatch if the version don't match.
+func (c *Client) RestoreRevision(ctx context.Context, artworkID int, rev int, version int, author string) (*Artwork, error) {
+	ctx, cancel := c.withTimeout(ctx, "RestoreRevision")
+	defer cancel()
+
+	revision, err := c.GetRevision(ctx, artworkID, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	artwork := revision.Artwork
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to begin the Artwork transaction. Err: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := findArtwork(ctx, tx, "SELECT "+selectColumns+" FROM artworks WHERE id=? FOR UPDATE", artworkID)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == nil {
+		return nil, newError(ErrNotFound, "The Artwork with id: %d has been purged, it can't be restored", artworkID)
+	}
+
+	if version != 0 && version != previous.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	artwork.DeletedAt = previous.DeletedAt
+	artwork.Version = previous.Version + 1
+
+	restoreColumns := columnsExcept(columns, "id", "deleted_at", "version")
+	sqlStatement := "UPDATE artworks SET " + columnAssignments(restoreColumns) + ", version=version+1 WHERE id=?"
+
+	if _, err := tx.ExecContext(ctx, sqlStatement, append(columnValues(restoreColumns, artwork), artworkID)...); isDuplicate(err) {
+		return nil, conflictError(ctx, tx, artwork)
+	} else if err != nil {
+		return nil, fmt.Errorf("Unable to execute the Artwork RESTORE statement. Err: %w", err)
+	}
+
+	if err := recordRevision(ctx, tx, "RESTORE", author, previous, artwork); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Unable to commit the Artwork transaction. Err: %w", err)
+	}
+
+	return artwork, nil
+}
+
+// recordRevision stores a new Revision for an Artwork change, it should be
+// called on the same transaction that performs the change.
+//
+// tx: The change transaction.
+// action: One of INSERT, UPDATE, DELETE, UNDELETE, PURGE or RESTORE.
+// author: Who performs the change.
+// previous: The Artwork state before the change, empty for new Artworks.
+// current: The Artwork state after the change, empty for purged Artworks.
+//
+// Returns an error if any.
+func recordRevision(ctx context.Context, tx *sql.Tx, action string, author string, previous, current *Artwork) error {
+	snapshot := current
+	if action == "PURGE" {
+		snapshot = previous
+	}
+
+	changes, err := json.Marshal(diffArtworks(previous, current))
+	if err != nil {
+		return fmt.Errorf("Unable to encode the Revision changes. Err: %w", err)
+	}
+
+	state, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("Unable to encode the Revision Artwork. Err: %w", err)
+	}
+
+	var rev int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(rev), 0) + 1 FROM artwork_revisions WHERE artwork_id=? FOR UPDATE",
+		current.ID).Scan(&rev)
+	if err != nil {
+		return fmt.Errorf("Unable to query the artwork_revisions table. Err: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO artwork_revisions (artwork_id, rev, action, author, created_at, changes, snapshot) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?)",
+		current.ID, rev, action, author, time.Now().Unix(), changes, state)
+	if err != nil {
+		return fmt.Errorf("Unable to execute the Revision INSERT statement. Err: %w", err)
+	}
+
+	return nil
+}
+
+// diffArtworks returns the field-level changes between two Artwork states,
+// by Artwork JSON field name. The id and version are never part of the
+// changes.
+func diffArtworks(previous, current *Artwork) map[string]Change {
+	changes := map[string]Change{}
+
+	for _, col := range columnsExcept(columns, "id", "version") {
+		from := reflect.ValueOf(col.field(previous)).Elem().Interface()
+		to := reflect.ValueOf(col.field(current)).Elem().Interface()
+
+		if !reflect.DeepEqual(from, to) {
+			changes[col.name] = Change{From: from, To: to}
+		}
+	}
+
+	return changes
+}
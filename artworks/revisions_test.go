@@ -0,0 +1,314 @@
+package artworks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestDiffArtworks(t *testing.T) {
+	tests := []struct {
+		previous        *Artwork
+		current         *Artwork
+		expectedChanges map[string]Change
+	}{
+		{
+			previous: &Artwork{},
+			current:  &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631},
+			expectedChanges: map[string]Change{
+				"rei":        {From: "", To: "#EU82REE"},
+				"created_at": {From: int64(0), To: int64(1489140631)},
+			},
+		},
+		{
+			previous:        &Artwork{ID: 1, Est: "Bueno"},
+			current:         &Artwork{ID: 1, Est: "Regular"},
+			expectedChanges: map[string]Change{"est": {From: "Bueno", To: "Regular"}},
+		},
+		{
+			previous:        &Artwork{ID: 1, Est: "Bueno"},
+			current:         &Artwork{ID: 1, Est: "Bueno"},
+			expectedChanges: map[string]Change{},
+		},
+	}
+
+	for _, test := range tests {
+		changes := diffArtworks(test.previous, test.current)
+		if !reflect.DeepEqual(changes, test.expectedChanges) {
+			t.Errorf("The returned changes don't match the expected. Got: %v Expected: %v", changes, test.expectedChanges)
+		}
+	}
+}
+
+func TestGetRevisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	revisionColumns := []string{"rev", "artwork_id", "action", "author", "created_at", "changes"}
+
+	tests := []struct {
+		artworkID      int
+		mock           func()
+		expectedLength int
+		expectedError  bool
+	}{
+		{
+			artworkID: 1, // an Artwork with history
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(revisionColumns).
+						AddRow(1, 1, "INSERT", "jcleira", 1489140631, `{"rei":{"from":"","to":"#EU82REE"}}`))
+			},
+			expectedLength: 1,
+		},
+		{
+			artworkID: 2, // an existing Artwork without history
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows(revisionColumns))
+				mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\?").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+						AddRow(columnArgs(columns, &Artwork{ID: 2, Rei: "#F423432"})...))
+			},
+			expectedLength: 0,
+		},
+		{
+			artworkID: 3, // a missing Artwork
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(revisionColumns))
+				mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\?").
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test.mock()
+
+		revisions, err := artworksClient.GetRevisions(context.Background(), test.artworkID)
+		if (err != nil) != test.expectedError {
+			t.Errorf("GetRevisions error don't match the expected for %d. Got: %v", test.artworkID, err)
+			continue
+		}
+
+		if len(revisions) != test.expectedLength {
+			t.Errorf("The returned Revisions don't match the expected. Got: %d Expected: %d", len(revisions), test.expectedLength)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestDeleteArtworkRevision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	artwork := &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\? AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, artwork)...))
+	mock.ExpectPrepare("UPDATE artworks SET deleted_at").ExpectExec().
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO artwork_revisions").
+		WithArgs(1, 2, "DELETE", "jcleira", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	if err := artworksClient.DeleteArtwork(context.Background(), 1, 0, "jcleira"); err != nil {
+		t.Errorf("DeleteArtwork returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	deletedAt := int64(1489140700)
+	current := &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631, Tit: "Vista del puerto", DeletedAt: &deletedAt, Version: 3}
+	restored := &Artwork{ID: 1, Rei: "#EU82REE", CreatedAt: 1489140631, Tit: "Vista del puerto de Mahón", DeletedAt: &deletedAt, Version: 4}
+
+	expectRevision := func() {
+		mock.ExpectQuery("SELECT rev, artwork_id, action, author, created_at, changes, snapshot FROM artwork_revisions").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"rev", "artwork_id", "action", "author", "created_at", "changes", "snapshot"}).
+				AddRow(1, 1, "INSERT", "jcleira", 1489140631, []byte(`{}`),
+					[]byte(`{"id": 1, "rei": "#EU82REE", "created_at": 1489140631, "tit": "Vista del puerto de Mahón", "version": 1}`)))
+		mock.ExpectBegin()
+	}
+
+	expectRevision()
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\? FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).AddRow(columnArgs(columns, current)...))
+	mock.ExpectExec("UPDATE artworks SET (.+), version=version\\+1 WHERE id=\\?").
+		WithArgs(append(columnArgs(columnsExcept(columns, "id", "deleted_at", "version"), restored), 1)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(4))
+	mock.ExpectExec("INSERT INTO artwork_revisions").
+		WithArgs(1, 4, "RESTORE", "jcleira", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectCommit()
+
+	artwork, err := artworksClient.RestoreRevision(context.Background(), 1, 1, 0, "jcleira")
+	if err != nil {
+		t.Errorf("RestoreRevision returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if !reflect.DeepEqual(artwork, restored) {
+		t.Errorf("The restored Artwork don't match the expected, the deletion state should be kept. Got: %+v Expected: %+v",
+			artwork, restored)
+	}
+
+	expectRevision()
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\? FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).AddRow(columnArgs(columns, current)...))
+	mock.ExpectRollback()
+
+	if _, err := artworksClient.RestoreRevision(context.Background(), 1, 1, 2, "jcleira"); err != ErrVersionMismatch {
+		t.Errorf("RestoreRevision should fail on a version mismatch. Got: %v", err)
+	}
+
+	expectRevision()
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\? FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")))
+	mock.ExpectRollback()
+
+	if _, err := artworksClient.RestoreRevision(context.Background(), 1, 1, 0, "jcleira"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreRevision should not restore a purged Artwork. Got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestGetRevisionHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}/history/{rev:[0-9]+}", GetRevisionHandler(&FakeClient{}))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		url        string
+		statusCode int
+	}{
+		{
+			url:        "/artworks/1/history/1", // ok
+			statusCode: http.StatusOK,
+		},
+		{
+			url:        "/artworks/1/history/foo", // non numeric rev on url
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(fmt.Sprint(server.URL, test.url))
+		if err != nil {
+			t.Errorf("Unable to perform GetRevision request. Err: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response status code don't match the expected. Got: %d Expected: %d", resp.StatusCode, test.statusCode)
+			return
+		}
+	}
+}
+
+func TestRestoreRevisionHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}/history/{rev:[0-9]+}/restore", RestoreRevisionHandler(&FakeClient{})).Methods("POST")
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		ifMatch    string
+		statusCode int
+	}{
+		{ifMatch: "", statusCode: http.StatusOK},
+		{ifMatch: `"1"`, statusCode: http.StatusOK},
+		{ifMatch: `"2"`, statusCode: http.StatusPreconditionFailed},
+		{ifMatch: `foo`, statusCode: http.StatusPreconditionFailed},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprint(server.URL, "/artworks/1/history/1/restore"), nil)
+		if test.ifMatch != "" {
+			req.Header.Set("If-Match", test.ifMatch)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("Unable to perform RestoreRevision request. Err: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.statusCode {
+			t.Errorf("The response status code don't match the expected for If-Match %s. Got: %d Expected: %d",
+				test.ifMatch, resp.StatusCode, test.statusCode)
+		}
+	}
+}
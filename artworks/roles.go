@@ -0,0 +1,208 @@
+package artworks
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jcleira/handler/handler"
+)
+
+// Role is what an authenticated Principal is allowed to do, see
+// rolePermissions.
+type Role string
+
+// The available roles, from the least to the most privileged.
+const (
+	// RoleViewer is meant for external researchers, it's read-only and the
+	// sensitive fields are hidden.
+	RoleViewer Role = "viewer"
+	// RoleEditor is meant for the volunteers entering data, it can create and
+	// edit Artworks but not delete them.
+	RoleEditor Role = "editor"
+	// RoleCurator is meant for the collection curators, it can do everything
+	// on the catalogue but purging Artworks.
+	RoleCurator Role = "curator"
+	// RoleAdmin is meant for the people operating the API, it can also purge
+	// Artworks and manage the API keys.
+	RoleAdmin Role = "admin"
+)
+
+// Permission is a kind of operation on the catalogue.
+type Permission string
+
+// The available permissions, routes are bound to a single one on
+// ConfigureHandlers.
+const (
+	// PermRead allows fetching, listing, searching and exporting Artworks.
+	PermRead Permission = "read"
+	// PermReadSensitive allows reading the SensitiveFields.
+	PermReadSensitive Permission = "read-sensitive"
+	// PermWrite allows creating and editing Artworks and their images.
+	PermWrite Permission = "write"
+	// PermDelete allows deleting Artworks and their images, and managing the
+	// trash.
+	PermDelete Permission = "delete"
+	// PermAdmin allows the operations that can't be undone, purging Artworks,
+	// and managing the API keys.
+	PermAdmin Permission = "admin"
+)
+
+// rolePermissions are the permissions granted to each role.
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:  {PermRead},
+	RoleEditor:  {PermRead, PermReadSensitive, PermWrite},
+	RoleCurator: {PermRead, PermReadSensitive, PermWrite, PermDelete},
+	RoleAdmin:   {PermRead, PermReadSensitive, PermWrite, PermDelete, PermAdmin},
+}
+
+// SensitiveFields are the Artwork fields (by JSON field name) only shown to
+// the roles granted the given permission, the valuation and the location.
+// They are blanked for any other role and they can't be used as filters.
+var SensitiveFields = map[string]Permission{
+	"ubi": PermReadSensitive,
+	"vap": PermReadSensitive,
+}
+
+// ParseRole validates a role name.
+//
+// name: The role name, an empty one is a RoleViewer.
+//
+// Returns:
+// The Role.
+// An ErrValidation error if it isn't one of the available roles.
+func ParseRole(name string) (Role, error) {
+	if name == "" {
+		return RoleViewer, nil
+	}
+
+	role := Role(name)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", &Error{Kind: ErrValidation, Detail: fmt.Sprintf("Unknown role %q", name),
+			Fields: []FieldError{{Field: "role", Message: "should be one of viewer, editor, curator or admin"}}}
+	}
+
+	return role, nil
+}
+
+// Can tells whether the Role is granted the given permission.
+func (role Role) Can(perm Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize wraps a CustomHandler so it's only reached by the Principals
+// whose Role is granted the given permission, the response is 403 Forbidden
+// otherwise. It should be used behind the Authenticator Middleware, requests
+// without a Principal get a 401 Unauthorized.
+//
+// perm: The permission required.
+// h: The CustomHandler to protect.
+//
+// Returns a CustomHandler ready to be added to a HTTP server / router.
+func Authorize(perm Permission, h handler.CustomHandler) handler.CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handler.HTTPError {
+		principal := PrincipalFromContext(r.Context())
+		if principal == nil {
+			return httpError(newError(ErrUnauthenticated, "The request is not authenticated"), http.StatusUnauthorized)
+		}
+
+		if !principal.Role.Can(perm) {
+			return httpError(newError(ErrForbidden, "The %s role is not allowed the %s permission", principal.Role, perm),
+				http.StatusForbidden)
+		}
+
+		return h(w, r)
+	}
+}
+
+// canReadField tells whether the request Principal could read an Artwork
+// field, requests without a Principal are not restricted as they only
+// happen when the handlers are served without the Authenticator.
+func canReadField(r *http.Request, field string) bool {
+	perm, ok := SensitiveFields[field]
+	if !ok {
+		return true
+	}
+
+	principal := PrincipalFromContext(r.Context())
+	return principal == nil || principal.Role.Can(perm)
+}
+
+// redactArtwork hides the SensitiveFields the request Principal can't read.
+//
+// r: The request.
+// artwork: The Artwork to be sent, it's never modified.
+//
+// Returns the given Artwork, or a copy with the restricted fields blanked.
+func redactArtwork(r *http.Request, artwork *Artwork) *Artwork {
+	var redacted *Artwork
+
+	for _, col := range columns {
+		if canReadField(r, col.name) {
+			continue
+		}
+
+		if redacted == nil {
+			copied := *artwork
+			redacted = &copied
+		}
+
+		if field, ok := col.field(redacted).(*string); ok {
+			*field = ""
+		}
+	}
+
+	if redacted == nil {
+		return artwork
+	}
+
+	return redacted
+}
+
+// redactRevision hides the SensitiveFields the request Principal can't read
+// from a Revision, both its changes and its Artwork.
+//
+// r: The request.
+// revision: The Revision to be sent, it's never modified.
+//
+// Returns the given Revision, or a redacted copy.
+func redactRevision(r *http.Request, revision *Revision) *Revision {
+	redacted := *revision
+
+	if revision.Changes != nil {
+		redacted.Changes = map[string]Change{}
+		for field, change := range revision.Changes {
+			if canReadField(r, field) {
+				redacted.Changes[field] = change
+			}
+		}
+	}
+
+	if revision.Artwork != nil {
+		redacted.Artwork = redactArtwork(r, revision.Artwork)
+	}
+
+	return &redacted
+}
+
+// checkFilters rejects the listing filters on fields the request Principal
+// can't read, they would disclose them.
+//
+// r: The request.
+// opts: The listing settings.
+//
+// Returns an ErrForbidden error if any filter is restricted.
+func checkFilters(r *http.Request, opts *ListOptions) error {
+	for field := range opts.Filters {
+		if !canReadField(r, field) {
+			return newError(ErrForbidden, "The %s field can't be used as a filter by the current role", field)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package artworks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// withRole is a middleware authenticating every request as a Principal with
+// the given role, as the Authenticator Middleware does.
+func withRole(role Role) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := &Principal{Subject: "jcleira", Method: AuthJWT, Role: role}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	artworksClient := &FakeClient{}
+
+	tests := []struct {
+		role       Role
+		method     string
+		url        string
+		body       string
+		statusCode int
+	}{
+		{role: RoleViewer, method: "GET", url: "/artworks/1", statusCode: http.StatusOK},
+		{role: RoleViewer, method: "PATCH", url: "/artworks/1", body: `{"tit": "Retrato"}`, statusCode: http.StatusForbidden},
+		{role: RoleViewer, method: "GET", url: "/artworks/trash", statusCode: http.StatusForbidden},
+		{role: RoleViewer, method: "GET", url: "/artworks?ubi=Sala%203", statusCode: http.StatusForbidden},
+		{role: RoleViewer, method: "GET", url: "/artworks?aut=Anónimo", statusCode: http.StatusOK},
+		{role: RoleEditor, method: "GET", url: "/artworks?ubi=Sala%203", statusCode: http.StatusOK},
+		{role: RoleEditor, method: "PATCH", url: "/artworks/1", body: `{"tit": "Retrato"}`, statusCode: http.StatusOK},
+		{role: RoleEditor, method: "DELETE", url: "/artworks/1", statusCode: http.StatusForbidden},
+		{role: RoleCurator, method: "DELETE", url: "/artworks/1", statusCode: http.StatusNoContent},
+		{role: RoleCurator, method: "GET", url: "/artworks/trash", statusCode: http.StatusOK},
+		{role: RoleAdmin, method: "POST", url: "/artworks/1/restore", statusCode: http.StatusOK},
+		{role: RoleCurator, method: "DELETE", url: "/artworks/trash/1", statusCode: http.StatusForbidden},
+		{role: RoleAdmin, method: "DELETE", url: "/artworks/trash/1", statusCode: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		r := mux.NewRouter()
+		r.Use(withRole(test.role))
+		r.Handle("/artworks", ProblemHandler(Authorize(PermRead, GetArtworksHandler(artworksClient)))).Methods("GET")
+		r.Handle("/artworks/trash", ProblemHandler(Authorize(PermDelete, GetTrashHandler(artworksClient)))).Methods("GET")
+		r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermRead, GetArtworkHandler(artworksClient)))).Methods("GET")
+		r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermWrite, PatchArtworkHandler(artworksClient)))).Methods("PATCH")
+		r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermDelete, DeleteArtworkHandler(artworksClient)))).Methods("DELETE")
+		r.Handle("/artworks/{id:[0-9]+}/restore", ProblemHandler(Authorize(PermDelete, RestoreArtworkHandler(artworksClient)))).Methods("POST")
+		r.Handle("/artworks/trash/{id:[0-9]+}", ProblemHandler(Authorize(PermAdmin, PurgeArtworkHandler(artworksClient)))).Methods("DELETE")
+
+		req := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		if test.method == "PATCH" {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.statusCode {
+			t.Errorf("The response Status Code don't match for the %s role on %s %s Got: %d Expected: %d",
+				test.role, test.method, test.url, w.Code, test.statusCode)
+		}
+	}
+
+	r := mux.NewRouter()
+	r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(Authorize(PermRead, GetArtworkHandler(artworksClient)))).Methods("GET", "OPTIONS")
+
+	for _, method := range []string{"GET", "OPTIONS"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/artworks/1", nil))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s requests without a Principal should not be authorized. Got: %d", method, w.Code)
+		}
+	}
+}
+
+func TestSensitiveFields(t *testing.T) {
+	artworksClient := &FakeClient{}
+
+	tests := []struct {
+		role      Role
+		sensitive bool
+	}{
+		{role: RoleViewer, sensitive: false},
+		{role: RoleEditor, sensitive: true},
+		{role: RoleCurator, sensitive: true},
+	}
+
+	for _, test := range tests {
+		r := mux.NewRouter()
+		r.Use(withRole(test.role))
+		r.Handle("/artworks/{id:[0-9]+}", ProblemHandler(GetArtworkHandler(artworksClient))).Methods("GET")
+		r.Handle("/artworks/{id:[0-9]+}/history/{rev:[0-9]+}", ProblemHandler(GetRevisionHandler(artworksClient))).Methods("GET")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/artworks/1", nil))
+
+		var artwork Artwork
+		json.NewDecoder(w.Body).Decode(&artwork)
+
+		if (artwork.Ubi != "" && artwork.Vap != "") != test.sensitive || artwork.Rei != "#EU82REE" {
+			t.Errorf("The Artwork sensitive fields don't match for the %s role Got: %+v", test.role, artwork)
+		}
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/artworks/1/history/1", nil))
+
+		var revision Revision
+		json.NewDecoder(w.Body).Decode(&revision)
+
+		if _, ok := revision.Changes["ubi"]; ok != test.sensitive || (revision.Artwork.Ubi != "") != test.sensitive {
+			t.Errorf("The Revision sensitive fields don't match for the %s role Got: %+v", test.role, revision)
+		}
+
+		if _, ok := revision.Changes["rei"]; !ok {
+			t.Errorf("The Revision non sensitive changes should be kept for the %s role Got: %+v", test.role, revision)
+		}
+	}
+
+	artwork, _ := artworksClient.GetArtwork(context.Background(), 1)
+	req := httptest.NewRequest("GET", "/artworks/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), principalKey{}, &Principal{Role: RoleViewer}))
+
+	if redactArtwork(req, artwork); artwork.Ubi == "" {
+		t.Errorf("redactArtwork should never modify the given Artwork")
+	}
+}
@@ -0,0 +1,203 @@
+package artworks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// column maps an artworks table column to its Artwork struct field.
+//
+// name: The column name on the artworks table.
+// numeric: Whether the column holds an integer, text otherwise.
+// field: Returns a pointer to the Artwork field holding the column value.
+type column struct {
+	name    string
+	numeric bool
+	field   func(*Artwork) interface{}
+}
+
+// columns is the single column-to-field mapping of the artworks table, every
+// read and write on the table is built from it, so the columns order doesn't
+// depend on the table definition.
+var columns = []column{
+	{"id", true, func(a *Artwork) interface{} { return &a.ID }},
+	{"rei", false, func(a *Artwork) interface{} { return &a.Rei }},
+	{"created_at", true, func(a *Artwork) interface{} { return &a.CreatedAt }},
+	{"ubi", false, func(a *Artwork) interface{} { return &a.Ubi }},
+	{"pro", false, func(a *Artwork) interface{} { return &a.Pro }},
+	{"adq", false, func(a *Artwork) interface{} { return &a.Adq }},
+	{"reg", false, func(a *Artwork) interface{} { return &a.Reg }},
+	{"nom", false, func(a *Artwork) interface{} { return &a.Nom }},
+	{"tit", false, func(a *Artwork) interface{} { return &a.Tit }},
+	{"aut", false, func(a *Artwork) interface{} { return &a.Aut }},
+	{"fec", false, func(a *Artwork) interface{} { return &a.Fec }},
+	{"lug", false, func(a *Artwork) interface{} { return &a.Lug }},
+	{"ico", false, func(a *Artwork) interface{} { return &a.Ico }},
+	{"tip", false, func(a *Artwork) interface{} { return &a.Tip }},
+	{"tec", false, func(a *Artwork) interface{} { return &a.Tec }},
+	{"sop", false, func(a *Artwork) interface{} { return &a.Sop }},
+	{"mat", false, func(a *Artwork) interface{} { return &a.Mat }},
+	{"tin", false, func(a *Artwork) interface{} { return &a.Tin }},
+	{"dim", false, func(a *Artwork) interface{} { return &a.Dim }},
+	{"hue", false, func(a *Artwork) interface{} { return &a.Hue }},
+	{"ins", false, func(a *Artwork) interface{} { return &a.Ins }},
+	{"des", false, func(a *Artwork) interface{} { return &a.Des }},
+	{"est", false, func(a *Artwork) interface{} { return &a.Est }},
+	{"uso", false, func(a *Artwork) interface{} { return &a.Uso }},
+	{"prp", false, func(a *Artwork) interface{} { return &a.Prp }},
+	{"vap", false, func(a *Artwork) interface{} { return &a.Vap }},
+	{"publicable", true, func(a *Artwork) interface{} { return &a.Publicable }},
+	{"deleted_at", true, func(a *Artwork) interface{} { return &a.DeletedAt }},
+	{"version", true, func(a *Artwork) interface{} { return &a.Version }},
+}
+
+// selectColumns is the explicit column list used on the artworks table
+// SELECT statements.
+var selectColumns = columnNames(columns)
+
+// insertColumns are the columns written on new Artworks, the id is generated
+// by the database, new Artworks are never deleted and start on version 1.
+var insertColumns = columnsExcept(columns, "id", "deleted_at", "version")
+
+// updateColumns are the columns written on existing Artworks, the id and
+// creation date are never updated, deletion has its own statements and the
+// version is increased by the database.
+var updateColumns = columnsExcept(columns, "id", "created_at", "deleted_at", "version")
+
+// columnsExcept returns the given columns but the named ones.
+func columnsExcept(cols []column, names ...string) []column {
+	var result []column
+
+	for _, col := range cols {
+		excluded := false
+		for _, name := range names {
+			if col.name == name {
+				excluded = true
+			}
+		}
+
+		if !excluded {
+			result = append(result, col)
+		}
+	}
+
+	return result
+}
+
+// columnNames returns the comma separated names of the given columns.
+func columnNames(cols []column) string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.name
+	}
+
+	return strings.Join(names, ",")
+}
+
+// columnAssignments returns the comma separated SQL assignments (name=?) of
+// the given columns, to be used on UPDATE statements.
+func columnAssignments(cols []column) string {
+	return strings.Replace(columnNames(cols), ",", "=?,", -1) + "=?"
+}
+
+// placeholders returns n comma separated SQL placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// columnValues returns the given Artwork field pointers for the given
+// columns, to be used either as Scan destinations or as Exec arguments.
+func columnValues(cols []column, artwork *Artwork) []interface{} {
+	values := make([]interface{}, len(cols))
+	for i, col := range cols {
+		values[i] = col.field(artwork)
+	}
+
+	return values
+}
+
+// CheckSchema compares the live artworks table definition against the
+// Artwork struct column mapping, it's meant to be called on startup so the
+// server refuses to run against a drifted database.
+//
+// Returns an error describing every missing, unexpected or mistyped column.
+func (c *Client) CheckSchema(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx, "CheckSchema")
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx,
+		"SELECT column_name, data_type FROM information_schema.columns "+
+			"WHERE table_schema = DATABASE() AND table_name = 'artworks'")
+	if err != nil {
+		return fmt.Errorf("Unable to query the artworks table schema. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	live := map[string]string{}
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			return fmt.Errorf("Unable to map an artworks schema data row. Err: %w", err)
+		}
+		live[strings.ToLower(name)] = strings.ToLower(dataType)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("Unable to iterate on artworks schema data. Err %w", err)
+	}
+
+	if len(live) == 0 {
+		return fmt.Errorf("Unable to find the artworks table on the database")
+	}
+
+	return compareSchema(live, columns)
+}
+
+// compareSchema compares the live columns (name to data type) against the
+// given column mapping.
+//
+// Returns an error describing every difference, nil if they match.
+func compareSchema(live map[string]string, cols []column) error {
+	var problems []string
+	mapped := map[string]bool{}
+
+	for _, col := range cols {
+		mapped[col.name] = true
+
+		dataType, ok := live[col.name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("missing column %s", col.name))
+			continue
+		}
+
+		if isNumericType(dataType) != col.numeric {
+			problems = append(problems, fmt.Sprintf("column %s has an unexpected type %s", col.name, dataType))
+		}
+	}
+
+	for name := range live {
+		if !mapped[name] {
+			problems = append(problems, fmt.Sprintf("unexpected column %s", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("The artworks table schema don't match the Artwork struct: %s", strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// isNumericType tells whether the given database data type holds integers.
+func isNumericType(dataType string) bool {
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return true
+	}
+
+	return false
+}
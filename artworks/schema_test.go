@@ -0,0 +1,125 @@
+package artworks
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// columnArgs returns the driver values expected for the given columns.
+func columnArgs(cols []column, artwork *Artwork) []driver.Value {
+	var args []driver.Value
+	for _, value := range columnValues(cols, artwork) {
+		arg, _ := driver.DefaultParameterConverter.ConvertValue(value)
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+func TestCompareSchema(t *testing.T) {
+	valid := map[string]string{}
+	for _, col := range columns {
+		valid[col.name] = "varchar"
+		if col.numeric {
+			valid[col.name] = "int"
+		}
+	}
+
+	tests := []struct {
+		change        func(map[string]string)
+		expectedError bool
+	}{
+		{
+			change:        func(live map[string]string) {},
+			expectedError: false,
+		},
+		{
+			change:        func(live map[string]string) { delete(live, "vap") },
+			expectedError: true,
+		},
+		{
+			change:        func(live map[string]string) { live["icc"] = "varchar" },
+			expectedError: true,
+		},
+		{
+			change:        func(live map[string]string) { live["created_at"] = "varchar" },
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		live := map[string]string{}
+		for name, dataType := range valid {
+			live[name] = dataType
+		}
+		test.change(live)
+
+		err := compareSchema(live, columns)
+		if (err != nil) != test.expectedError {
+			t.Errorf("The returned error from compareSchema don't match the test case. Got: %v", err)
+		}
+	}
+}
+
+func TestAddUpdateArtworkColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	artwork := &Artwork{ID: 2, Rei: "#F423432", CreatedAt: 1489140633, Vap: "1000", Publicable: true}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO artworks \\(rei,created_at,ubi,(.+),vap,publicable\\)").ExpectExec().
+		WithArgs(columnArgs(insertColumns, artwork)...).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO artwork_revisions").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := artworksClient.AddUpdateArtwork(context.Background(), "INSERT", artwork, "jcleira"); err != nil {
+		t.Errorf("AddUpdateArtwork returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if artwork.ID != 3 {
+		t.Errorf("The inserted Artwork ID don't match Got: %d Expected: 3", artwork.ID)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE id=\\? AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, artwork)...))
+	mock.ExpectPrepare("UPDATE artworks SET rei=\\?,ubi=\\?,(.+),vap=\\?,publicable=\\?, version=version\\+1 WHERE id=\\?").ExpectExec().
+		WithArgs(append(columnArgs(updateColumns, artwork), int64(3))...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM artwork_revisions").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO artwork_revisions").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	if err := artworksClient.AddUpdateArtwork(context.Background(), "UPDATE", artwork, "jcleira"); err != nil {
+		t.Errorf("AddUpdateArtwork returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
@@ -0,0 +1,170 @@
+package artworks
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultSearchLimit is the amount of search results returned when no
+	// limit has been requested.
+	DefaultSearchLimit = 20
+
+	// snippetContext is the amount of characters shown around the first match
+	// of a highlighted snippet.
+	snippetContext = 40
+
+	// searchColumns are the descriptive Artworks columns covered by the
+	// artworks_search FULLTEXT index, the order must match the index definition.
+	searchColumns = "tit,des,ico,ins,aut"
+)
+
+// SearchResult is a single full-text search match, the Artwork along with its
+// relevance Score and the highlighted snippets of the matching fields.
+//
+// Highlights keys are the Artwork JSON field names (tit, des, ico, ins, aut),
+// matching terms are wrapped on <em></em> tags and the text is HTML escaped.
+type SearchResult struct {
+	Artwork    Artwork           `json:"artwork"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// accents maps the accented characters found on Spanish and Catalan texts to
+// their unaccented versions, it mimics the database collation for snippets.
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// fold returns the given text as lower case unaccented runes, the returned
+// slice keeps the same length as the text runes so positions match.
+func fold(text string) []rune {
+	runes := []rune(text)
+	folded := make([]rune, len(runes))
+
+	for i, r := range runes {
+		r = unicode.ToLower(r)
+		if unaccented, ok := accents[r]; ok {
+			r = unaccented
+		}
+		folded[i] = r
+	}
+
+	return folded
+}
+
+// highlight builds the highlighted snippets of the Artwork fields matching
+// any of the given search terms.
+//
+// artwork: The matching Artwork.
+// query: The search query as sent by the user.
+//
+// Returns the snippets by Artwork JSON field name.
+func highlight(artwork *Artwork, query string) map[string]string {
+	var terms [][]rune
+	for _, term := range strings.Fields(query) {
+		terms = append(terms, fold(term))
+	}
+
+	fields := map[string]string{
+		"tit": artwork.Tit,
+		"des": artwork.Des,
+		"ico": artwork.Ico,
+		"ins": artwork.Ins,
+		"aut": artwork.Aut,
+	}
+
+	highlights := map[string]string{}
+	for name, text := range fields {
+		if snippet, ok := snippet(text, terms); ok {
+			highlights[name] = snippet
+		}
+	}
+
+	return highlights
+}
+
+// snippet returns an excerpt of text around the first term match, with every
+// term match wrapped on <em></em> tags.
+//
+// Returns the snippet and whether any term matched.
+func snippet(text string, terms [][]rune) (string, bool) {
+	runes := []rune(text)
+	folded := fold(text)
+
+	// matches holds the length of the term matching at every position.
+	matches := make([]int, len(runes))
+	first := -1
+
+	for i := range folded {
+		for _, term := range terms {
+			if len(term) > 0 && hasPrefix(folded[i:], term) && len(term) > matches[i] {
+				matches[i] = len(term)
+				if first == -1 {
+					first = i
+				}
+			}
+		}
+	}
+
+	if first == -1 {
+		return "", false
+	}
+
+	start := first - snippetContext
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetContext
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var result strings.Builder
+	if start > 0 {
+		result.WriteString("…")
+	}
+
+	for i := start; i < end; {
+		if matches[i] > 0 {
+			stop := i + matches[i]
+			if stop > len(runes) {
+				stop = len(runes)
+			}
+			result.WriteString("<em>")
+			result.WriteString(html.EscapeString(string(runes[i:stop])))
+			result.WriteString("</em>")
+			i = stop
+			continue
+		}
+		result.WriteString(html.EscapeString(string(runes[i])))
+		i++
+	}
+
+	if end < len(runes) {
+		result.WriteString("…")
+	}
+
+	return result.String(), true
+}
+
+// hasPrefix tells whether the given runes start with prefix.
+func hasPrefix(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if runes[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,87 @@
+package artworks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		artwork            Artwork
+		query              string
+		expectedHighlights map[string]string
+	}{
+		{
+			artwork:            Artwork{Tit: "Vista del puerto de Mahón"},
+			query:              "mahon",
+			expectedHighlights: map[string]string{"tit": "Vista del puerto de <em>Mahón</em>"},
+		},
+		{
+			artwork:            Artwork{Tit: "Retrato", Aut: "Francisco de Goya"},
+			query:              "GOYA retrato",
+			expectedHighlights: map[string]string{"tit": "<em>Retrato</em>", "aut": "Francisco de <em>Goya</em>"},
+		},
+		{
+			artwork:            Artwork{Des: "Óleo <sobre> lienzo"},
+			query:              "oleo",
+			expectedHighlights: map[string]string{"des": "<em>Óleo</em> &lt;sobre&gt; lienzo"},
+		},
+		{
+			artwork:            Artwork{Tit: "Retrato de dama"},
+			query:              "puerto",
+			expectedHighlights: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		highlights := highlight(&test.artwork, test.query)
+		if fmt.Sprint(highlights) != fmt.Sprint(test.expectedHighlights) {
+			t.Errorf("The returned highlights don't match the expected. Got: %v Expected: %v", highlights, test.expectedHighlights)
+		}
+	}
+}
+
+func TestSearchArtworksHandler(t *testing.T) {
+	server := httptest.NewServer(SearchArtworksHandler(&FakeClient{}))
+	defer server.Close()
+
+	tests := []struct {
+		params          string
+		statusCode      int
+		expectedResults int
+	}{
+		{params: "?q=Mahon", statusCode: http.StatusOK, expectedResults: 1},
+		{params: "?q=foo", statusCode: http.StatusOK, expectedResults: 0},
+		{params: "?q=", statusCode: http.StatusBadRequest},
+		{params: "?q=Mahon&limit=foo", statusCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		r, err := http.Get(fmt.Sprint(server.URL, test.params))
+		if err != nil {
+			t.Errorf("Unable to perform SearchArtworks request. Err: %s", err)
+			return
+		}
+		defer r.Body.Close()
+
+		if r.StatusCode != test.statusCode {
+			t.Errorf("The response Status Code don't match Got: %d Expected: %d", r.StatusCode, test.statusCode)
+			return
+		}
+
+		if test.statusCode == http.StatusOK {
+			var results []SearchResult
+			if err := json.NewDecoder(r.Body).Decode(&results); err != nil {
+				t.Errorf("Unable to Unmarshal the Body content of the SearchArtworks request: %s", err)
+				return
+			}
+
+			if len(results) != test.expectedResults {
+				t.Errorf("The returned number of results don't match Got: %d Expected: %d", len(results), test.expectedResults)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package artworks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Storage is implemented by the backends storing the Artworks images
+// content, the images metadata is kept on the artwork_images table.
+//
+// Keys are slash separated paths generated by the Client, as in
+// 'artworks/1/5f0c3b1e9d2a7c48'.
+type Storage interface {
+	// Put stores the whole content under the given key and returns the stored
+	// size, a partially stored content should be removed on errors.
+	Put(key string, content io.Reader) (int64, error)
+
+	// Get opens the content stored under the given key, it returns an
+	// ErrNotFound error if there is none.
+	Get(key string) (io.ReadCloser, error)
+
+	// Delete removes the content stored under the given key, deleting a
+	// missing key is a no-op.
+	Delete(key string) error
+}
+
+// LocalStorage is the local filesystem Storage, every key is a file under the
+// Dir directory. When the API runs on several hosts Dir should be a shared
+// volume.
+type LocalStorage struct {
+	Dir string
+}
+
+// Put stores the content on a temporary file renamed to the key path once
+// completely written, so readers never get a partial content.
+//
+// key: The content key.
+// content: The content to store.
+//
+// Returns:
+// The stored size.
+// An error otherwise.
+func (s *LocalStorage) Put(key string, content io.Reader) (int64, error) {
+	name := s.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return 0, fmt.Errorf("Unable to create the storage directory. Err: %s", err)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(name), ".upload-")
+	if err != nil {
+		return 0, fmt.Errorf("Unable to create the storage file. Err: %s", err)
+	}
+	defer os.Remove(file.Name())
+
+	size, err := io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("Unable to write the storage file. Err: %s", err)
+	}
+
+	if err := os.Rename(file.Name(), name); err != nil {
+		return 0, fmt.Errorf("Unable to move the storage file. Err: %s", err)
+	}
+
+	return size, nil
+}
+
+// Get opens the file stored under the given key.
+//
+// key: The content key.
+//
+// Returns:
+// The file, it should be closed by the caller.
+// An error otherwise, ErrNotFound if the file doesn't exist.
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, newError(ErrNotFound, "Unable to find the stored content %s", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open the storage file. Err: %s", err)
+	}
+
+	return file, nil
+}
+
+// Delete removes the file stored under the given key.
+//
+// key: The content key.
+//
+// Returns an error if any.
+func (s *LocalStorage) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to remove the storage file. Err: %s", err)
+	}
+
+	return nil
+}
+
+// path returns the file path of a key, keys are cleaned so they never point
+// outside the Dir directory.
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// newStorageKey returns a new random key for an Artwork image.
+func newStorageKey(artworkID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("Unable to generate the storage key. Err: %s", err)
+	}
+
+	return fmt.Sprintf("artworks/%d/%s", artworkID, hex.EncodeToString(random)), nil
+}
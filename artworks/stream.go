@@ -0,0 +1,180 @@
+package artworks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/jcleira/handler/handler"
+)
+
+// NDJSONContentType is the content type of the Artworks streamed as
+// newline delimited JSON, an Artwork per line.
+const NDJSONContentType = "application/x-ndjson"
+
+// streamFlushRows is the amount of Artworks written between flushes, so the
+// client gets them as they are read without flushing on every row.
+const streamFlushRows = 50
+
+// artworksWriter writes the streamed Artworks one by one, Close should be
+// called once all of them are written.
+type artworksWriter interface {
+	Write(*Artwork) error
+	Close() error
+}
+
+// projection returns the representation an Artwork is encoded as, nil
+// projections encode the Artwork itself.
+type projection func(*Artwork) interface{}
+
+// project returns the given Artwork representation.
+func (p projection) project(artwork *Artwork) interface{} {
+	if p == nil {
+		return artwork
+	}
+
+	return p(artwork)
+}
+
+// jsonArrayWriter writes the Artworks as a JSON array, using the same
+// representation as the rest of the API unless a projection is given.
+type jsonArrayWriter struct {
+	w          io.Writer
+	count      int
+	projection projection
+}
+
+func newJSONArrayWriter(w io.Writer) (artworksWriter, error) {
+	return newProjectedJSONArrayWriter(w, nil)
+}
+
+func newProjectedJSONArrayWriter(w io.Writer, p projection) (artworksWriter, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, fmt.Errorf("Unable to write the Artworks. Err: %s", err)
+	}
+
+	return &jsonArrayWriter{w: w, projection: p}, nil
+}
+
+func (jw *jsonArrayWriter) Write(artwork *Artwork) error {
+	data, err := json.Marshal(jw.projection.project(artwork))
+	if err != nil {
+		return fmt.Errorf("Unable to encode the Artwork. Err: %s", err)
+	}
+
+	if jw.count > 0 {
+		data = append([]byte(","), data...)
+	}
+	jw.count++
+
+	if _, err := jw.w.Write(data); err != nil {
+		return fmt.Errorf("Unable to write the Artworks. Err: %s", err)
+	}
+
+	return nil
+}
+
+func (jw *jsonArrayWriter) Close() error {
+	if _, err := io.WriteString(jw.w, "]\n"); err != nil {
+		return fmt.Errorf("Unable to write the Artworks. Err: %s", err)
+	}
+
+	return nil
+}
+
+// ndjsonWriter writes the Artworks as newline delimited JSON, an Artwork per
+// line, using the same representation as the rest of the API unless a
+// projection is given.
+type ndjsonWriter struct {
+	encoder    *json.Encoder
+	projection projection
+}
+
+func newNDJSONWriter(w io.Writer) (artworksWriter, error) {
+	return newProjectedNDJSONWriter(w, nil)
+}
+
+func newProjectedNDJSONWriter(w io.Writer, p projection) (artworksWriter, error) {
+	return &ndjsonWriter{encoder: json.NewEncoder(w), projection: p}, nil
+}
+
+func (nw *ndjsonWriter) Write(artwork *Artwork) error {
+	if err := nw.encoder.Encode(nw.projection.project(artwork)); err != nil {
+		return fmt.Errorf("Unable to write the Artworks. Err: %s", err)
+	}
+
+	return nil
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+// streamArtworks writes the Artworks matching the given ListOptions as they
+// are read from the database, flushing them every streamFlushRows. The
+// SensitiveFields the request Principal can't read are blanked.
+//
+// The response starts on the first Artwork, so a failing query still gets a
+// problem response. Once started, failures can only be logged and the
+// response is left unfinished, as when the client is gone as the request
+// context is canceled.
+//
+// artworksClient: The Artworks client.
+// w: The response writer.
+// r: The request, its context cancels the streaming.
+// opts: The listing settings.
+// contentType: The response Content-Type.
+// newWriter: Returns the artworksWriter writing the response body.
+//
+// Returns a HTTPError if the response couldn't be started.
+func streamArtworks(artworksClient ArtworksController, w http.ResponseWriter, r *http.Request, opts *ListOptions,
+	contentType string, newWriter func(io.Writer) (artworksWriter, error)) *handler.HTTPError {
+	flusher, _ := w.(http.Flusher)
+
+	var writer artworksWriter
+	start := func() (err error) {
+		w.Header().Set("Content-Type", contentType)
+
+		writer, err = newWriter(w)
+		return err
+	}
+
+	count := 0
+	err := artworksClient.WalkArtworks(r.Context(), opts, func(artwork *Artwork) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(redactArtwork(r, artwork)); err != nil {
+			return err
+		}
+
+		if count++; flusher != nil && count%streamFlushRows == 0 {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+
+	if err == nil && writer == nil {
+		err = start()
+	}
+
+	if err != nil && writer == nil {
+		return httpError(err, http.StatusInternalServerError)
+	}
+
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		log.Printf("%s %s failed while streaming. Err: %s", r.Method, r.URL.Path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package artworks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestQueryArtworksPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	opts := NewListOptions()
+	opts.Limit = 1
+
+	mock.ExpectQuery("SELECT COUNT(.+) FROM artworks WHERE deleted_at IS NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE deleted_at IS NULL ORDER BY id ASC LIMIT 2 OFFSET \\?").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, &Artwork{ID: 1, Rei: "#EU82REE"})...).
+			AddRow(columnArgs(columns, &Artwork{ID: 2, Rei: "#F423432"})...))
+
+	page, err := artworksClient.QueryArtworksPage(context.Background(), opts)
+	if err != nil {
+		t.Errorf("QueryArtworksPage returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if page.Total != 3 || page.NextCursor != opts.nextCursor(&Artwork{ID: 1}) || page.Artworks != nil {
+		t.Errorf("The returned page from QueryArtworksPage don't match the expected. Got: %+v", page)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestWalkArtworksCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	artworksClient := Client{
+		DB: db,
+	}
+
+	opts := NewListOptions()
+	opts.Limit = 2
+
+	mock.ExpectQuery("SELECT (.+) FROM artworks WHERE deleted_at IS NULL ORDER BY id ASC LIMIT \\? OFFSET \\?").
+		WithArgs(2, 0).
+		WillReturnRows(sqlmock.NewRows(strings.Split(selectColumns, ",")).
+			AddRow(columnArgs(columns, &Artwork{ID: 1, Rei: "#EU82REE"})...).
+			AddRow(columnArgs(columns, &Artwork{ID: 2, Rei: "#F423432"})...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	walked := 0
+	err = artworksClient.WalkArtworks(ctx, opts, func(artwork *Artwork) error {
+		walked++
+		cancel()
+		return nil
+	})
+
+	if err == nil || walked != 1 {
+		t.Errorf("WalkArtworks should stop once the context is canceled. Walked: %d Err: %v", walked, err)
+	}
+}
+
+func TestGetArtworksHandlerStreaming(t *testing.T) {
+	server := httptest.NewServer(GetArtworksHandler(&FakeClient{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?limit=1")
+	if err != nil {
+		t.Errorf("Unable to perform GetArtworks request. Err: %s", err)
+		return
+	}
+
+	var artworks []Artwork
+	err = json.NewDecoder(resp.Body).Decode(&artworks)
+	resp.Body.Close()
+
+	if err != nil || len(artworks) != 1 || artworks[0].ID != 1 {
+		t.Errorf("The JSON array don't match the mocked Artworks page. Got: %+v Err: %v", artworks, err)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Accept", NDJSONContentType)
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("Unable to perform GetArtworks request. Err: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != NDJSONContentType {
+		t.Errorf("The response Content-Type don't match Got: %s Expected: %s", resp.Header.Get("Content-Type"), NDJSONContentType)
+	}
+
+	var lines int
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var artwork Artwork
+		if err := json.Unmarshal(scanner.Bytes(), &artwork); err != nil || artwork.ID == 0 {
+			t.Errorf("The NDJSON line don't match an Artwork. Got: %s", scanner.Text())
+		}
+		lines++
+	}
+
+	if lines != 2 {
+		t.Errorf("The NDJSON lines don't match the mocked Artworks. Got: %d", lines)
+	}
+}
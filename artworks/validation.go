@@ -0,0 +1,97 @@
+package artworks
+
+import (
+	_ "embed" // The Artwork JSON Schema is embedded on the binary.
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaContentType is the JSON Schema media type.
+const SchemaContentType = "application/schema+json"
+
+// ArtworkSchema is the Artwork JSON Schema, every Artwork write is validated
+// against it. It mirrors the artworks table limits (rei VARCHAR(9), the rest
+// of the text columns VARCHAR(255)).
+//
+//go:embed artwork_schema.json
+var ArtworkSchema []byte
+
+// artworkSchema is the compiled ArtworkSchema.
+var artworkSchema = mustLoadSchema(ArtworkSchema)
+
+// FieldError is a validation failure on a single Artwork JSON field, Row is
+// only set on imports, as the failing CSV row.
+//
+// Example:
+// {
+//   field: 'rei',
+//   message: 'String length must be less than or equal to 9'
+// }
+type FieldError struct {
+	Row     int    `json:"row,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateArtwork validates an Artwork against the Artwork JSON Schema.
+//
+// artwork: The Artwork to validate.
+//
+// Returns an ErrValidation error holding the failures by field, if any.
+func ValidateArtwork(artwork *Artwork) error {
+	return validate(gojsonschema.NewGoLoader(artwork))
+}
+
+// ValidateArtworkJSON validates an Artwork JSON document against the Artwork
+// JSON Schema, so type errors are also reported by field.
+//
+// data: The Artwork JSON document.
+//
+// Returns an ErrValidation error holding the failures by field, if any.
+func ValidateArtworkJSON(data []byte) error {
+	return validate(gojsonschema.NewBytesLoader(data))
+}
+
+// validate validates a document against the Artwork JSON Schema.
+func validate(document gojsonschema.JSONLoader) error {
+	result, err := artworkSchema.Validate(document)
+	if err != nil {
+		return newError(ErrValidation, "The Artwork should be a JSON object")
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	var fields []FieldError
+	var names []string
+
+	for _, resultErr := range result.Errors() {
+		field := resultErr.Field()
+		if property, ok := resultErr.Details()["property"].(string); ok {
+			field = property
+		}
+
+		fields = append(fields, FieldError{Field: field, Message: resultErr.Description()})
+		names = append(names, field)
+	}
+
+	return &Error{
+		Kind:   ErrValidation,
+		Detail: fmt.Sprintf("The Artwork is not valid on: %s", strings.Join(names, ", ")),
+		Fields: fields,
+	}
+}
+
+// mustLoadSchema compiles a JSON Schema, as the schema is embedded an invalid
+// one is a programming error.
+func mustLoadSchema(data []byte) *gojsonschema.Schema {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		panic(fmt.Sprintf("Unable to load the Artwork JSON schema. Err: %s", err))
+	}
+
+	return schema
+}
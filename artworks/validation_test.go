@@ -0,0 +1,106 @@
+package artworks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateArtworkJSON(t *testing.T) {
+	tests := []struct {
+		body           string
+		expectedFields []string
+	}{
+		{body: `{"rei": "#EU82REE", "tit": "Retrato de dama"}`, expectedFields: nil},
+		{body: `{"tit": "Retrato de dama"}`, expectedFields: []string{"rei"}},
+		{body: `{"rei": "#EU82REE0000"}`, expectedFields: []string{"rei"}},
+		{body: `{"rei": "#EU82REE", "aut": 5}`, expectedFields: []string{"aut"}},
+		{body: `{"rei": "#EU82REE", "tit": "` + strings.Repeat("a", 256) + `"}`, expectedFields: []string{"tit"}},
+		{body: `{"rei": "#EU82REE", "titulo": "Retrato de dama"}`, expectedFields: []string{"titulo"}},
+	}
+
+	for _, test := range tests {
+		err := ValidateArtworkJSON([]byte(test.body))
+		if (err != nil) != (test.expectedFields != nil) {
+			t.Errorf("The returned error from ValidateArtworkJSON don't match the test case for %.40s. Got: %v", test.body, err)
+			continue
+		}
+
+		if err == nil {
+			continue
+		}
+
+		var artworksErr *Error
+		if !errors.As(err, &artworksErr) || !errors.Is(err, ErrValidation) {
+			t.Errorf("The returned error should be an ErrValidation one. Got: %v", err)
+			continue
+		}
+
+		var fields []string
+		for _, field := range artworksErr.Fields {
+			fields = append(fields, field.Field)
+		}
+
+		if strings.Join(fields, ",") != strings.Join(test.expectedFields, ",") {
+			t.Errorf("The failed fields don't match for %.40s. Got: %v Expected: %v", test.body, fields, test.expectedFields)
+		}
+	}
+}
+
+func TestValidateArtwork(t *testing.T) {
+	if err := ValidateArtwork(&Artwork{Rei: "#EU82REE", Tit: "Retrato de dama"}); err != nil {
+		t.Errorf("A valid Artwork should pass the validation. Err: %s", err)
+	}
+
+	if err := ValidateArtwork(&Artwork{Rei: "#EU82REE", Dim: strings.Repeat("1", 256)}); !errors.Is(err, ErrValidation) {
+		t.Errorf("An Artwork exceeding the column limits should fail the validation. Got: %v", err)
+	}
+}
+
+func TestAddArtworkHandlerValidation(t *testing.T) {
+	server := httptest.NewServer(ProblemHandler(AddArtworkHandler(&FakeClient{})))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", bytes.NewBufferString(`{"rei": "#EU82REE0000"}`))
+	if err != nil {
+		t.Errorf("Unable to perform AddArtwork request. Err: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("The response Status Code don't match Got: %d Expected: %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	var problem Problem
+	json.NewDecoder(resp.Body).Decode(&problem)
+
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "rei" || problem.Errors[0].Message == "" {
+		t.Errorf("The problem field errors don't match the expected. Got: %+v", problem.Errors)
+	}
+}
+
+func TestGetSchemaHandler(t *testing.T) {
+	server := httptest.NewServer(GetSchemaHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Errorf("Unable to perform GetSchema request. Err: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != SchemaContentType {
+		t.Errorf("The response Content-Type don't match Got: %s Expected: %s", resp.Header.Get("Content-Type"), SchemaContentType)
+	}
+
+	var schema map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil || schema["title"] != "Artwork" {
+		t.Errorf("The response body should be the Artwork JSON Schema. Got: %v", schema)
+	}
+}
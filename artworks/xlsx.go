@@ -0,0 +1,128 @@
+package artworks
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// xlsxParts are the fixed parts of a single sheet XLSX workbook, the sheet
+// itself is streamed by xlsxWriter.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Artworks" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter writes a single sheet XLSX workbook row by row, so it never
+// holds more than a row in memory. Cells are either numbers or inline
+// strings, there are no shared strings nor styles.
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+}
+
+// newXLSXWriter starts a XLSX workbook on the given writer.
+//
+// w: Where the workbook is written.
+//
+// Returns:
+// The xlsxWriter ready to write rows.
+// An error if any.
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	xw := &xlsxWriter{zip: zip.NewWriter(w)}
+
+	for _, part := range xlsxParts {
+		f, err := xw.zip.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to create the XLSX %s part. Err: %s", part.name, err)
+		}
+
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, fmt.Errorf("Unable to write the XLSX %s part. Err: %s", part.name, err)
+		}
+	}
+
+	sheet, err := xw.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create the XLSX sheet. Err: %s", err)
+	}
+	xw.sheet = sheet
+
+	_, err = io.WriteString(xw.sheet, xml.Header+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to write the XLSX sheet. Err: %s", err)
+	}
+
+	return xw, nil
+}
+
+// Write appends a row to the sheet, int64 values are written as numbers and
+// anything else as text.
+func (xw *xlsxWriter) Write(values []interface{}) error {
+	if _, err := io.WriteString(xw.sheet, "<row>"); err != nil {
+		return fmt.Errorf("Unable to write the XLSX row. Err: %s", err)
+	}
+
+	for _, value := range values {
+		var err error
+
+		switch v := value.(type) {
+		case int64:
+			_, err = io.WriteString(xw.sheet, `<c><v>`+strconv.FormatInt(v, 10)+`</v></c>`)
+		default:
+			if _, err = io.WriteString(xw.sheet, `<c t="inlineStr"><is><t xml:space="preserve">`); err == nil {
+				if err = xml.EscapeText(xw.sheet, []byte(fmt.Sprint(v))); err == nil {
+					_, err = io.WriteString(xw.sheet, `</t></is></c>`)
+				}
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("Unable to write the XLSX cell. Err: %s", err)
+		}
+	}
+
+	if _, err := io.WriteString(xw.sheet, "</row>"); err != nil {
+		return fmt.Errorf("Unable to write the XLSX row. Err: %s", err)
+	}
+
+	return nil
+}
+
+// Close ends the sheet and the workbook, the underlying writer is not
+// closed.
+func (xw *xlsxWriter) Close() error {
+	if _, err := io.WriteString(xw.sheet, "</sheetData></worksheet>"); err != nil {
+		return fmt.Errorf("Unable to write the XLSX sheet. Err: %s", err)
+	}
+
+	if err := xw.zip.Close(); err != nil {
+		return fmt.Errorf("Unable to close the XLSX workbook. Err: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,519 @@
+// Package config loads the artworks-api server settings.
+//
+// Settings are layered, each layer overriding the previous one:
+//
+// 1. The defaults, see Default.
+// 2. The YAML config file, its top-level settings and then the ones of the
+// running environment section.
+// 3. The ARTWORKS_* environment variables.
+// 4. The command line flags.
+//
+// Every setting is declared once on the Config struct, its tags tell its
+// YAML key (yaml), environment variable (env), flag name (flag) and flag
+// description (usage).
+//
+// Config file example:
+//
+//	http:
+//	  address: ":3000"
+//	database:
+//	  max_open_conns: 20
+//	environments:
+//	  development:
+//	    database:
+//	      dsn: artworks-dev-user:artworks-dev-pass@/mariadb?parseTime=true
+//	  production:
+//	    database:
+//	      dsn: tcp(mysql-service:3306)/artworks?parseTime=true
+//	      password_file: /run/secrets/mysql/mysql-password
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultPath is the config file read when no other is given, it's optional.
+const DefaultPath = "config.yml"
+
+// Config holds every setting needed to run the server.
+type Config struct {
+	Environment string     `yaml:"environment" env:"ARTWORKS_ENVIRONMENT" flag:"environment" usage:"Running environment, one of the config file environments"`
+	HTTP        HTTP       `yaml:"http"`
+	Database    Database   `yaml:"database"`
+	Migrations  Migrations `yaml:"migrations"`
+	Images      Images     `yaml:"images"`
+	Auth        Auth       `yaml:"auth"`
+	Public      Public     `yaml:"public"`
+	CORS        CORS       `yaml:"cors"`
+	Log         Log        `yaml:"log"`
+}
+
+// HTTP holds the listeners settings.
+type HTTP struct {
+	Address      string `yaml:"address" env:"ARTWORKS_HTTP_ADDRESS" flag:"address" usage:"API listen address"`
+	AdminAddress string `yaml:"admin_address" env:"ARTWORKS_HTTP_ADMIN_ADDRESS" flag:"admin-address" usage:"Admin API listen address"`
+
+	ReadTimeout      time.Duration `yaml:"read_timeout" env:"ARTWORKS_HTTP_READ_TIMEOUT" flag:"read-timeout" usage:"Maximum duration to read a request, body included"`
+	WriteTimeout     time.Duration `yaml:"write_timeout" env:"ARTWORKS_HTTP_WRITE_TIMEOUT" flag:"write-timeout" usage:"Maximum duration to write a response, streamed listings included"`
+	IdleTimeout      time.Duration `yaml:"idle_timeout" env:"ARTWORKS_HTTP_IDLE_TIMEOUT" flag:"idle-timeout" usage:"Maximum duration to keep an idle connection open"`
+	MaxHeaderBytes   int           `yaml:"max_header_bytes" env:"ARTWORKS_HTTP_MAX_HEADER_BYTES" flag:"max-header-bytes" usage:"Maximum request headers size"`
+	ShutdownTimeout  time.Duration `yaml:"shutdown_timeout" env:"ARTWORKS_HTTP_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"Maximum duration to drain the connections on shutdown"`
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env:"ARTWORKS_HTTP_READINESS_TIMEOUT" flag:"readiness-timeout" usage:"Maximum duration of every /readyz component check"`
+}
+
+// Database holds the MariaDB connection settings. User and Password, when
+// set, replace the ones on the DSN, so the DSN could be kept on the config
+// file and the credentials on secrets.
+type Database struct {
+	DSN             string        `yaml:"dsn" env:"ARTWORKS_DATABASE_DSN" flag:"database-dsn" usage:"MariaDB data source name"`
+	DSNFile         string        `yaml:"dsn_file" env:"ARTWORKS_DATABASE_DSN_FILE" flag:"database-dsn-file" usage:"File holding the MariaDB data source name"`
+	User            string        `yaml:"user" env:"ARTWORKS_DATABASE_USER" flag:"database-user" usage:"MariaDB user, it replaces the data source name one"`
+	UserFile        string        `yaml:"user_file" env:"ARTWORKS_DATABASE_USER_FILE" flag:"database-user-file" usage:"File holding the MariaDB user"`
+	Password        string        `yaml:"password" env:"ARTWORKS_DATABASE_PASSWORD"`
+	PasswordFile    string        `yaml:"password_file" env:"ARTWORKS_DATABASE_PASSWORD_FILE" flag:"database-password-file" usage:"File holding the MariaDB password"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"ARTWORKS_DATABASE_MAX_OPEN_CONNS" flag:"database-max-open-conns" usage:"Maximum open database connections, 0 for unlimited"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"ARTWORKS_DATABASE_MAX_IDLE_CONNS" flag:"database-max-idle-conns" usage:"Maximum idle database connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"ARTWORKS_DATABASE_CONN_MAX_LIFETIME" flag:"database-conn-max-lifetime" usage:"Maximum database connection lifetime, 0 for unlimited"`
+	QueryTimeout    time.Duration `yaml:"query_timeout" env:"ARTWORKS_DATABASE_QUERY_TIMEOUT" flag:"query-timeout" usage:"Database work timeout per request, 0 to disable"`
+}
+
+// Migrations holds the schema migrations settings.
+type Migrations struct {
+	OnStart     bool          `yaml:"on_start" env:"ARTWORKS_MIGRATIONS_ON_START" flag:"migrate-on-start" usage:"Apply the pending schema migrations on startup"`
+	LockTimeout time.Duration `yaml:"lock_timeout" env:"ARTWORKS_MIGRATIONS_LOCK_TIMEOUT" flag:"migrations-lock-timeout" usage:"How long to wait for other replicas migrating the schema"`
+}
+
+// Images holds the Artworks images settings.
+type Images struct {
+	Dir               string `yaml:"dir" env:"ARTWORKS_IMAGES_DIR" flag:"images-dir" usage:"Artworks images storage directory"`
+	DerivativeWorkers int    `yaml:"derivative_workers" env:"ARTWORKS_IMAGES_DERIVATIVE_WORKERS" flag:"derivative-workers" usage:"Image derivatives background workers"`
+	MaxPixels         int    `yaml:"max_pixels" env:"ARTWORKS_IMAGES_MAX_PIXELS" flag:"images-max-pixels" usage:"Maximum width x height of the uploaded images"`
+}
+
+// Auth holds the JWT bearer tokens settings, tokens are disabled without a
+// secret or a public key.
+type Auth struct {
+	JWTSecretFile    string `yaml:"jwt_secret_file" env:"ARTWORKS_AUTH_JWT_SECRET_FILE" flag:"jwt-secret-file" usage:"HS256 JWT secret file, bearer tokens are disabled without a secret or public key"`
+	JWTPublicKeyFile string `yaml:"jwt_public_key_file" env:"ARTWORKS_AUTH_JWT_PUBLIC_KEY_FILE" flag:"jwt-public-key-file" usage:"RS256 JWT PEM encoded public key file"`
+	JWTIssuer        string `yaml:"jwt_issuer" env:"ARTWORKS_AUTH_JWT_ISSUER" flag:"jwt-issuer" usage:"Expected JWT issuer (iss claim), not checked if empty"`
+	JWTAudience      string `yaml:"jwt_audience" env:"ARTWORKS_AUTH_JWT_AUDIENCE" flag:"jwt-audience" usage:"Expected JWT audience (aud claim), not checked if empty"`
+}
+
+// Public holds the public API settings.
+type Public struct {
+	Fields []string `yaml:"fields" env:"ARTWORKS_PUBLIC_FIELDS" flag:"public-fields" usage:"Artwork fields served on the public API, comma separated, the built-in ones if empty"`
+}
+
+// CORS holds the cross-origin requests settings, they are disabled without
+// allowed origins.
+type CORS struct {
+	AllowedOrigins []string      `yaml:"allowed_origins" env:"ARTWORKS_CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins" usage:"Origins allowed on cross-origin requests, comma separated, * for any"`
+	AllowedMethods []string      `yaml:"allowed_methods" env:"ARTWORKS_CORS_ALLOWED_METHODS" flag:"cors-allowed-methods" usage:"Methods allowed on cross-origin requests, comma separated"`
+	AllowedHeaders []string      `yaml:"allowed_headers" env:"ARTWORKS_CORS_ALLOWED_HEADERS" flag:"cors-allowed-headers" usage:"Request headers allowed on cross-origin requests, comma separated"`
+	ExposedHeaders []string      `yaml:"exposed_headers" env:"ARTWORKS_CORS_EXPOSED_HEADERS" flag:"cors-exposed-headers" usage:"Response headers exposed to cross-origin requests, comma separated"`
+	MaxAge         time.Duration `yaml:"max_age" env:"ARTWORKS_CORS_MAX_AGE" flag:"cors-max-age" usage:"How long the preflight responses could be cached"`
+}
+
+// Log holds the logging settings.
+type Log struct {
+	Format string `yaml:"format" env:"ARTWORKS_LOG_FORMAT" flag:"log-format" usage:"Log format, text or json"`
+	Output string `yaml:"output" env:"ARTWORKS_LOG_OUTPUT" flag:"log-output" usage:"Log file, stderr if empty"`
+}
+
+// Default returns the Config with the default settings, there is no default
+// database DSN.
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		HTTP: HTTP{
+			Address:      ":3000",
+			AdminAddress: "127.0.0.1:3001",
+
+			ReadTimeout:      30 * time.Second,
+			WriteTimeout:     2 * time.Minute,
+			IdleTimeout:      2 * time.Minute,
+			MaxHeaderBytes:   1 << 20,
+			ShutdownTimeout:  20 * time.Second,
+			ReadinessTimeout: 2 * time.Second,
+		},
+		Database: Database{
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+			QueryTimeout:    5 * time.Second,
+		},
+		Migrations: Migrations{
+			LockTimeout: 5 * time.Minute,
+		},
+		Images: Images{
+			Dir:               "images",
+			DerivativeWorkers: 2,
+			MaxPixels:         100000000,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "X-API-Key"},
+			ExposedHeaders: []string{"ETag", "Link", "Location", "X-Total-Count"},
+			MaxAge:         10 * time.Minute,
+		},
+		Log: Log{
+			Format: "text",
+		},
+	}
+}
+
+// Load builds the Config from the layered settings.
+//
+// The config file is the -config flag one, the ARTWORKS_CONFIG environment
+// variable one or DefaultPath, which is the only one that could be missing.
+// When the file has an environments section, the running environment should
+// be one of them.
+//
+// name: The program name, used on the flags usage.
+// args: The command line arguments, without the program name.
+// getenv: Returns an environment variable value, as os.Getenv.
+//
+// Returns:
+// The Config.
+// The remaining command line arguments, after the flags.
+// An error if any layer can't be read or the resulting Config is not valid,
+// flag.ErrHelp if the usage was requested.
+func Load(name string, args []string, getenv func(string) string) (*Config, []string, error) {
+	cfg := Default()
+	settings := settingsOf(cfg)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", "", "Config file path (default "+DefaultPath+", or the ARTWORKS_CONFIG environment variable)")
+
+	flags := map[string]string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			fs.Var(&flagValue{setting: s, flags: flags}, s.flag, s.usage)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	required := true
+	if *path == "" {
+		*path = getenv("ARTWORKS_CONFIG")
+	}
+	if *path == "" {
+		*path, required = DefaultPath, false
+	}
+
+	environment := flags["environment"]
+	if environment == "" {
+		environment = getenv("ARTWORKS_ENVIRONMENT")
+	}
+
+	if err := cfg.loadFile(*path, required, environment); err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.env); s.env != "" && value != "" {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("Unable to read the %s environment variable. Err: %s", s.env, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := flags[s.flag]; ok {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("Unable to read the -%s flag. Err: %s", s.flag, err)
+			}
+		}
+	}
+
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// loadFile reads the config file, its top-level settings and then the ones
+// of the running environment section.
+//
+// path: The config file path.
+// required: Whether the file should exist.
+// environment: The running environment, the file one (or the default one)
+// is used if empty.
+//
+// Returns an error if any.
+func (cfg *Config) loadFile(path string, required bool, environment string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		if environment != "" {
+			cfg.Environment = environment
+		}
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("Unable to read the config file. Err: %s", err)
+	}
+
+	var file struct {
+		Environments map[string]interface{} `yaml:"environments"`
+	}
+
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("Unable to parse the config file %s. Err: %s", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("Unable to parse the config file %s. Err: %s", path, err)
+	}
+
+	if environment != "" {
+		cfg.Environment = environment
+	}
+
+	if len(file.Environments) == 0 {
+		return nil
+	}
+
+	section, ok := file.Environments[cfg.Environment]
+	if !ok {
+		return fmt.Errorf("Unable to find the %s environment on the config file %s", cfg.Environment, path)
+	}
+
+	data, err = yaml.Marshal(section)
+	if err != nil {
+		return fmt.Errorf("Unable to read the %s environment settings. Err: %s", cfg.Environment, err)
+	}
+
+	environment = cfg.Environment
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("Unable to parse the %s environment settings. Err: %s", environment, err)
+	}
+	cfg.Environment = environment
+
+	return nil
+}
+
+// resolveSecrets reads the settings kept on files, as mounted Kubernetes
+// secrets, and applies the database credentials to the DSN.
+//
+// Returns an error if any.
+func (cfg *Config) resolveSecrets() error {
+	db := &cfg.Database
+
+	for _, secret := range []struct {
+		path  string
+		value *string
+	}{
+		{db.DSNFile, &db.DSN},
+		{db.UserFile, &db.User},
+		{db.PasswordFile, &db.Password},
+	} {
+		if secret.path == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(secret.path)
+		if err != nil {
+			return fmt.Errorf("Unable to read the secret file. Err: %s", err)
+		}
+		*secret.value = strings.TrimSpace(string(data))
+	}
+
+	if db.DSN == "" || (db.User == "" && db.Password == "") {
+		return nil
+	}
+
+	dsn, err := mysql.ParseDSN(db.DSN)
+	if err != nil {
+		return fmt.Errorf("Unable to parse the database DSN. Err: %s", err)
+	}
+
+	if db.User != "" {
+		dsn.User = db.User
+	}
+	if db.Password != "" {
+		dsn.Passwd = db.Password
+	}
+	db.DSN = dsn.FormatDSN()
+
+	return nil
+}
+
+// Validate checks the Config settings.
+//
+// Returns an error describing every invalid setting, nil if they are valid.
+func (cfg *Config) Validate() error {
+	var problems []string
+
+	if cfg.Database.DSN == "" {
+		problems = append(problems, "the database DSN is required")
+	}
+
+	if cfg.HTTP.Address == "" {
+		problems = append(problems, "the listen address is required")
+	}
+
+	if cfg.HTTP.MaxHeaderBytes < 0 {
+		problems = append(problems, "the max header size can't be negative")
+	}
+
+	if cfg.HTTP.ShutdownTimeout <= 0 || cfg.HTTP.ReadinessTimeout <= 0 {
+		problems = append(problems, "the shutdown and readiness timeouts should be positive")
+	}
+
+	if cfg.Database.MaxOpenConns < 0 || cfg.Database.MaxIdleConns < 0 {
+		problems = append(problems, "the database pool sizes can't be negative")
+	}
+
+	if cfg.Images.DerivativeWorkers < 1 {
+		problems = append(problems, "at least a derivative worker is required")
+	}
+
+	if cfg.Images.MaxPixels < 1 {
+		problems = append(problems, "the images max pixels should be positive")
+	}
+
+	if cfg.Log.Format != "text" && cfg.Log.Format != "json" {
+		problems = append(problems, "the log format should be text or json")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("The configuration is not valid: " + strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// setting is a Config field that could be set from environment variables
+// and flags.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value reflect.Value
+}
+
+// settingsOf returns the settings of the given Config, by walking its
+// fields tags.
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+
+			settings = append(settings, setting{
+				env:   field.Tag.Get("env"),
+				flag:  field.Tag.Get("flag"),
+				usage: field.Tag.Get("usage"),
+				value: v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+
+	return settings
+}
+
+// set parses the given text as the setting value, lists are comma
+// separated.
+func (s setting) set(text string) error {
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(text)
+	case bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", text)
+		}
+		s.value.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+		s.value.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", text)
+		}
+		s.value.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+
+	return nil
+}
+
+// String returns the setting value as text.
+func (s setting) String() string {
+	if list, ok := s.value.Interface().([]string); ok {
+		return strings.Join(list, ",")
+	}
+
+	return fmt.Sprint(s.value.Interface())
+}
+
+// flagValue is the flag.Value of a setting, the flags are only recorded
+// when parsed so they are applied after the other layers.
+type flagValue struct {
+	setting setting
+	flags   map[string]string
+}
+
+func (fv *flagValue) String() string {
+	if fv == nil || fv.flags == nil {
+		return ""
+	}
+
+	return fv.setting.String()
+}
+
+func (fv *flagValue) Set(text string) error {
+	if err := (setting{value: reflect.New(fv.setting.value.Type()).Elem()}).set(text); err != nil {
+		return err
+	}
+
+	fv.flags[fv.setting.flag] = text
+	return nil
+}
+
+// IsBoolFlag allows the boolean flags to be given without a value, as
+// -migrate-on-start.
+func (fv *flagValue) IsBoolFlag() bool {
+	return fv.setting.value.Kind() == reflect.Bool
+}
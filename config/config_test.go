@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfigFile = `
+http:
+  address: ":4000"
+database:
+  dsn: user:pass@/artworks
+  query_timeout: 2s
+environments:
+  development:
+    database:
+      max_open_conns: 10
+  production:
+    database:
+      dsn: tcp(mysql:3306)/artworks
+      password_file: %s
+    log:
+      format: json
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Errorf("Unable to create a temp dir. Err: %s", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	passwordFile := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(passwordFile, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Errorf("Unable to write the password file. Err: %s", err)
+		return
+	}
+
+	path := filepath.Join(dir, "settings.yml")
+	data := []byte(fmt.Sprintf(testConfigFile, passwordFile))
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Errorf("Unable to write the config file. Err: %s", err)
+		return
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		check         func(cfg *Config) bool
+		expectedError bool
+	}{
+		{
+			name: "file and development environment",
+			args: []string{"-config", path},
+			check: func(cfg *Config) bool {
+				return cfg.HTTP.Address == ":4000" && cfg.Database.DSN == "user:pass@/artworks" &&
+					cfg.Database.MaxOpenConns == 10 && cfg.Database.QueryTimeout == 2*time.Second &&
+					cfg.Images.Dir == "images" && cfg.Images.MaxPixels == 100000000 && cfg.Log.Format == "text"
+			},
+		},
+		{
+			name: "production environment with a password file",
+			env:  map[string]string{"ARTWORKS_CONFIG": path, "ARTWORKS_ENVIRONMENT": "production", "ARTWORKS_DATABASE_USER": "artworks"},
+			check: func(cfg *Config) bool {
+				return cfg.Environment == "production" && cfg.Database.MaxOpenConns == 20 &&
+					cfg.Database.DSN == "artworks:s3cr3t@tcp(mysql:3306)/artworks" && cfg.Log.Format == "json"
+			},
+		},
+		{
+			name: "flags override environment variables",
+			args: []string{"-config", path, "-address", ":5000", "-cors-allowed-origins", "https://a.org, https://b.org"},
+			env:  map[string]string{"ARTWORKS_HTTP_ADDRESS": ":6000", "ARTWORKS_DATABASE_QUERY_TIMEOUT": "1s"},
+			check: func(cfg *Config) bool {
+				return cfg.HTTP.Address == ":5000" && cfg.Database.QueryTimeout == time.Second &&
+					len(cfg.CORS.AllowedOrigins) == 2 && cfg.CORS.AllowedOrigins[1] == "https://b.org"
+			},
+		},
+		{
+			name: "no config file",
+			args: []string{"-database-dsn", "user:pass@/artworks"},
+			env:  map[string]string{"ARTWORKS_CONFIG": ""},
+			check: func(cfg *Config) bool {
+				return cfg.Environment == "development" && cfg.HTTP.Address == ":3000"
+			},
+		},
+		{
+			name:          "unknown environment",
+			args:          []string{"-config", path, "-environment", "staging"},
+			expectedError: true,
+		},
+		{
+			name:          "missing config file",
+			args:          []string{"-config", filepath.Join(dir, "missing.yml")},
+			expectedError: true,
+		},
+		{
+			name:          "missing database DSN",
+			args:          []string{"-address", ":5000"},
+			expectedError: true,
+		},
+		{
+			name:          "invalid duration",
+			args:          []string{"-config", path, "-query-timeout", "soon"},
+			expectedError: true,
+		},
+		{
+			name:          "non positive images max pixels",
+			args:          []string{"-config", path},
+			env:           map[string]string{"ARTWORKS_IMAGES_MAX_PIXELS": "0"},
+			expectedError: true,
+		},
+		{
+			name:          "invalid log format",
+			args:          []string{"-config", path},
+			env:           map[string]string{"ARTWORKS_LOG_FORMAT": "xml"},
+			expectedError: true,
+		},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("Unable to get the working dir. Err: %s", err)
+		return
+	}
+	// The working dir has no DefaultPath file, so it's optional.
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("Unable to change the working dir. Err: %s", err)
+		return
+	}
+	defer os.Chdir(wd)
+
+	for _, test := range tests {
+		getenv := func(key string) string {
+			return test.env[key]
+		}
+
+		cfg, _, err := Load("artworks-api", test.args, getenv)
+		if (err != nil) != test.expectedError {
+			t.Errorf("Load returned a non expected error for %s. Err: %v", test.name, err)
+			continue
+		}
+
+		if err == nil && !test.check(cfg) {
+			t.Errorf("The Config don't match for %s Got: %+v", test.name, cfg)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE artworks CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+ALTER TABLE artworks ADD FULLTEXT INDEX `artworks_search` (`tit`, `des`, `ico`, `ins`, `aut`);
+
+-- +migrate Down
+ALTER TABLE artworks DROP INDEX `artworks_search`;
+-- artworks was created with the server defaults, which are latin1 on MariaDB.
+ALTER TABLE artworks CONVERT TO CHARACTER SET latin1 COLLATE latin1_swedish_ci;
@@ -0,0 +1,24 @@
+-- +migrate Up
+-- icc isn't mapped on the Artwork struct, its values are kept on artworks_icc
+-- so the Down migration can restore them.
+CREATE TABLE artworks_icc (
+  id INT NOT NULL PRIMARY KEY,
+  icc VARCHAR(255) NOT NULL
+);
+INSERT INTO artworks_icc (id, icc) SELECT id, icc FROM artworks WHERE icc <> '';
+
+ALTER TABLE artworks
+  DROP COLUMN icc,
+  ADD COLUMN IF NOT EXISTS uso VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS prp VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS vap VARCHAR(255) NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE artworks
+  DROP COLUMN vap,
+  DROP COLUMN prp,
+  DROP COLUMN uso,
+  ADD COLUMN icc VARCHAR(255) NOT NULL DEFAULT '' AFTER ico;
+
+UPDATE artworks JOIN artworks_icc ON artworks_icc.id = artworks.id SET artworks.icc = artworks_icc.icc;
+DROP TABLE artworks_icc;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE artwork_revisions (
+  id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  artwork_id INT NOT NULL,
+  rev INT NOT NULL,
+  action VARCHAR(16) NOT NULL,
+  author VARCHAR(255) NOT NULL,
+  created_at INT NOT NULL,
+  changes TEXT NOT NULL,
+  snapshot TEXT NOT NULL,
+  UNIQUE INDEX `artwork_revisions_rev` (`artwork_id`, `rev`)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE artwork_revisions;
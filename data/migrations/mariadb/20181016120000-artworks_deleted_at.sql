@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE artworks ADD COLUMN deleted_at INT NULL DEFAULT NULL;
+ALTER TABLE artworks ADD INDEX `artworks_deleted_at` (`deleted_at`);
+
+-- +migrate Down
+ALTER TABLE artworks DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE artworks ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +migrate Down
+ALTER TABLE artworks DROP COLUMN version;
@@ -0,0 +1,19 @@
+-- +migrate Up
+-- MariaDB DDL isn't transactional, the migration is aborted before creating
+-- the index when there are empty or duplicated reis (trashed Artworks
+-- included), they have to be fixed by hand first:
+--
+--   SELECT rei, COUNT(*) FROM artworks GROUP BY rei HAVING COUNT(*) > 1 OR rei = '';
+--
+-- +migrate StatementBegin
+BEGIN NOT ATOMIC
+  IF EXISTS (SELECT rei FROM artworks GROUP BY rei HAVING COUNT(*) > 1 OR rei = '') THEN
+    SIGNAL SQLSTATE '45000'
+      SET MESSAGE_TEXT = 'artworks has empty or duplicated rei values, fix them before creating the artworks_rei unique index';
+  END IF;
+END;
+-- +migrate StatementEnd
+CREATE UNIQUE INDEX artworks_rei ON artworks (rei);
+
+-- +migrate Down
+DROP INDEX artworks_rei ON artworks;
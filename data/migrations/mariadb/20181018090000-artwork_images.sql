@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE artwork_images (
+  id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  artwork_id INT NOT NULL,
+  filename VARCHAR(255) NOT NULL,
+  content_type VARCHAR(255) NOT NULL,
+  size BIGINT NOT NULL,
+  storage_key VARCHAR(255) NOT NULL,
+  created_at INT NOT NULL,
+  INDEX `artwork_images_artwork_id` (`artwork_id`)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE artwork_images;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE api_keys (
+  id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  created_at INT NOT NULL,
+  revoked_at INT NULL,
+  UNIQUE INDEX `api_keys_key_hash` (`key_hash`)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE api_keys;
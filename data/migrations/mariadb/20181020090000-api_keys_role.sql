@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE api_keys ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'viewer' AFTER name;
+
+-- +migrate Down
+ALTER TABLE api_keys DROP COLUMN role;
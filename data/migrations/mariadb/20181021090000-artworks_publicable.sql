@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE artworks ADD COLUMN publicable TINYINT(1) NOT NULL DEFAULT 0 AFTER vap;
+ALTER TABLE artworks ADD INDEX `artworks_publicable` (`publicable`);
+
+-- +migrate Down
+ALTER TABLE artworks DROP INDEX `artworks_publicable`;
+ALTER TABLE artworks DROP COLUMN publicable;
@@ -0,0 +1,393 @@
+// Package migrations applies the MariaDB schema migrations embedded on the
+// binary, so every deployment runs the schema its code expects.
+//
+// The migrations are the sql-migrate ones at data/migrations/mariadb, they
+// are recorded on the same migrations table, so the databases migrated with
+// the sql-migrate tool (see dbconfig.yml) keep working.
+//
+// Concurrent runners, as the Kubernetes replicas starting at once, are
+// serialized by a MariaDB named lock (GET_LOCK).
+package migrations
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//go:embed mariadb/*.sql
+var files embed.FS
+
+const (
+	// table records the applied migrations, as sql-migrate does.
+	table = "migrations"
+	// lockName is the named lock held while a runner applies migrations.
+	lockName = "artworks_migrations"
+)
+
+// Migration is a schema change, with the statements to apply it (Up) and to
+// roll it back (Down).
+type Migration struct {
+	ID   string
+	Up   []string
+	Down []string
+}
+
+// Status is a Migration state on the database.
+type Status struct {
+	ID        string
+	AppliedAt *time.Time
+}
+
+// Load returns the embedded migrations sorted by ID, which starts with its
+// creation timestamp.
+//
+// Returns:
+// The Migrations.
+// An error if any of them can't be parsed.
+func Load() ([]*Migration, error) {
+	names, err := files.ReadDir("mariadb")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the embedded migrations. Err: %s", err)
+	}
+
+	var migrations []*Migration
+	for _, name := range names {
+		f, err := files.Open(path.Join("mariadb", name.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open the %s migration. Err: %s", name.Name(), err)
+		}
+
+		migration, err := Parse(name.Name(), f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].ID < migrations[j].ID
+	})
+
+	return migrations, nil
+}
+
+// Parse reads a sql-migrate formatted migration, its statements are split
+// by the semicolons ending a line, except inside the StatementBegin and
+// StatementEnd annotations.
+//
+// id: The migration ID, its file name.
+// r: The migration SQL.
+//
+// Returns:
+// The Migration.
+// An error if the migration is not well formed.
+func Parse(id string, r io.Reader) (*Migration, error) {
+	migration := &Migration{ID: id}
+
+	var (
+		statements *[]string
+		statement  strings.Builder
+		block      bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "-- +migrate ") {
+			fields := strings.Fields(strings.TrimPrefix(trimmed, "-- +migrate "))
+
+			if statements == nil && fields[0] != "Up" && fields[0] != "Down" {
+				return nil, fmt.Errorf("Unable to parse the %s migration, %s should follow Up or Down", id, fields[0])
+			}
+
+			switch fields[0] {
+			case "Up":
+				statements = &migration.Up
+			case "Down":
+				statements = &migration.Down
+			case "StatementBegin":
+				block = true
+			case "StatementEnd":
+				block = false
+				*statements = append(*statements, strings.TrimSpace(statement.String()))
+				statement.Reset()
+			default:
+				return nil, fmt.Errorf("Unable to parse the %s migration, unknown %s annotation", id, fields[0])
+			}
+			continue
+		}
+
+		if statements == nil || (!block && (trimmed == "" || strings.HasPrefix(trimmed, "--"))) {
+			continue
+		}
+
+		statement.WriteString(line + "\n")
+
+		if !block && strings.HasSuffix(trimmed, ";") {
+			*statements = append(*statements, strings.TrimSpace(statement.String()))
+			statement.Reset()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to read the %s migration. Err: %s", id, err)
+	}
+
+	if block || strings.TrimSpace(statement.String()) != "" {
+		return nil, fmt.Errorf("Unable to parse the %s migration, a statement is not terminated", id)
+	}
+
+	return migration, nil
+}
+
+// Runner applies the Migrations on a database.
+type Runner struct {
+	// DB should allow two open connections at least, one holds the lock
+	// while the other applies the migrations.
+	DB         *sql.DB
+	Migrations []*Migration
+
+	// LockTimeout is how long to wait for other runners to release the lock.
+	LockTimeout time.Duration
+}
+
+// Up applies every pending Migration, in order.
+//
+// ctx: The request context.
+//
+// Returns:
+// The applied Migrations IDs.
+// An error if any.
+func (r *Runner) Up(ctx context.Context) ([]string, error) {
+	var applied []string
+
+	err := r.locked(ctx, func() error {
+		statuses, err := r.status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i, status := range statuses {
+			if status.AppliedAt != nil {
+				continue
+			}
+
+			migration := r.Migrations[i]
+			if err := r.apply(ctx, migration.ID, migration.Up,
+				"INSERT INTO "+table+" (id, applied_at) VALUES (?, ?)", migration.ID, time.Now().UTC()); err != nil {
+				return err
+			}
+			applied = append(applied, migration.ID)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last applied Migration.
+//
+// ctx: The request context.
+//
+// Returns:
+// The rolled back Migration ID, empty if none was applied.
+// An error if any.
+func (r *Runner) Down(ctx context.Context) (string, error) {
+	var rolledBack string
+
+	err := r.locked(ctx, func() error {
+		statuses, err := r.status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0; i-- {
+			if statuses[i].AppliedAt == nil {
+				continue
+			}
+
+			migration := r.Migrations[i]
+			if err := r.apply(ctx, migration.ID, migration.Down,
+				"DELETE FROM "+table+" WHERE id=?", migration.ID); err != nil {
+				return err
+			}
+			rolledBack = migration.ID
+			return nil
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status returns every Migration state, in order.
+//
+// ctx: The request context.
+//
+// Returns:
+// The Migrations Status.
+// An error if any.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	if err := r.createTables(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.status(ctx)
+}
+
+// Check tells whether the database schema is at the expected version, every
+// Migration should be applied. It doesn't create the migrations tables, so
+// it could be used on the readiness checks.
+//
+// ctx: The request context.
+//
+// Returns an error naming the first pending Migration, if any.
+func (r *Runner) Check(ctx context.Context) error {
+	statuses, err := r.status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			return fmt.Errorf("The %s migration is not applied", status.ID)
+		}
+	}
+
+	return nil
+}
+
+// status returns every Migration state, the migrations table should exist.
+func (r *Runner) status(ctx context.Context) ([]Status, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, applied_at FROM "+table)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query the applied migrations. Err: %w", err)
+	}
+
+	defer rows.Close()
+
+	applied := map[string]time.Time{}
+	for rows.Next() {
+		var (
+			id        string
+			appliedAt mysql.NullTime
+		)
+		if err := rows.Scan(&id, &appliedAt); err != nil {
+			return nil, fmt.Errorf("Unable to map an applied migration data row. Err: %w", err)
+		}
+		applied[id] = appliedAt.Time
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to iterate on applied migrations data. Err %w", err)
+	}
+
+	statuses := make([]Status, len(r.Migrations))
+	for i, migration := range r.Migrations {
+		statuses[i].ID = migration.ID
+		if appliedAt, ok := applied[migration.ID]; ok {
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// apply runs a Migration statements and records it, within a transaction.
+// MariaDB commits the schema changes right away, so a failing migration
+// could be left half applied.
+func (r *Runner) apply(ctx context.Context, id string, statements []string, record string, args ...interface{}) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to begin the %s migration transaction. Err: %w", id, err)
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Unable to run the %s migration. Err: %w", id, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Unable to record the %s migration. Err: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Unable to commit the %s migration. Err: %w", id, err)
+	}
+
+	return nil
+}
+
+// createTables creates the migrations table, if it doesn't exist yet.
+func (r *Runner) createTables(ctx context.Context) error {
+	if _, err := r.DB.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS "+table+" (id VARCHAR(255) NOT NULL PRIMARY KEY, applied_at DATETIME NULL)"); err != nil {
+		return fmt.Errorf("Unable to create the migrations table. Err: %w", err)
+	}
+
+	return nil
+}
+
+// locked runs fn holding the migrations lock, waiting up to LockTimeout
+// for the other runners to release it.
+//
+// The lock is a MariaDB named lock held by a dedicated connection, so it's
+// released by the server when the runner connection is gone, as when a pod
+// is killed while migrating.
+func (r *Runner) locked(ctx context.Context, fn func() error) error {
+	if err := r.createTables(ctx); err != nil {
+		return err
+	}
+
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("Unable to get a connection to lock the migrations. Err: %w", err)
+	}
+
+	defer conn.Close()
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)",
+		lockName, int(r.LockTimeout.Seconds())).Scan(&acquired); err != nil {
+		return fmt.Errorf("Unable to lock the migrations. Err: %w", err)
+	}
+
+	if !acquired.Valid {
+		return fmt.Errorf("Unable to lock the migrations, the %s lock couldn't be acquired", lockName)
+	}
+
+	if acquired.Int64 == 0 {
+		return fmt.Errorf("Unable to lock the migrations, other runner held the %s lock for more than %s",
+			lockName, r.LockTimeout)
+	}
+
+	// The lock is released by the server anyway when the connection closes,
+	// a failed release is only logged.
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "DO RELEASE_LOCK(?)", lockName); err != nil {
+			log.Printf("Unable to release the %s migrations lock. Err: %s", lockName, err)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,183 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		sql           string
+		up            []string
+		down          []string
+		expectedError bool
+	}{
+		{
+			sql:  "-- +migrate Up\n-- A comment; ignored\nCREATE TABLE a (\n  id INT\n);\nALTER TABLE a ADD b INT;\n\n-- +migrate Down\nDROP TABLE a;\n",
+			up:   []string{"CREATE TABLE a (\n  id INT\n);", "ALTER TABLE a ADD b INT;"},
+			down: []string{"DROP TABLE a;"},
+		},
+		{
+			sql: "-- +migrate Up\n-- +migrate StatementBegin\nCREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN\n  SET NEW.b = 1;\nEND;\n-- +migrate StatementEnd\n",
+			up:  []string{"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN\n  SET NEW.b = 1;\nEND;"},
+		},
+		{sql: "-- +migrate Up\nCREATE TABLE a (id INT)\n", expectedError: true},
+		{sql: "-- +migrate StatementBegin\n", expectedError: true},
+		{sql: "-- +migrate Sideways\n", expectedError: true},
+	}
+
+	for _, test := range tests {
+		migration, err := Parse("test.sql", strings.NewReader(test.sql))
+		if (err != nil) != test.expectedError {
+			t.Errorf("Parse returned a non expected error for %q. Err: %v", test.sql, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if strings.Join(migration.Up, "|") != strings.Join(test.up, "|") {
+			t.Errorf("The Up statements don't match for %q Got: %q Expected: %q", test.sql, migration.Up, test.up)
+		}
+
+		if strings.Join(migration.Down, "|") != strings.Join(test.down, "|") {
+			t.Errorf("The Down statements don't match for %q Got: %q Expected: %q", test.sql, migration.Down, test.down)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Errorf("Load returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if len(migrations) == 0 {
+		t.Errorf("There are no embedded migrations")
+		return
+	}
+
+	for i, migration := range migrations {
+		if len(migration.Up) == 0 {
+			t.Errorf("The %s migration has no Up statements", migration.ID)
+		}
+
+		if i > 0 && migrations[i-1].ID >= migration.ID {
+			t.Errorf("The %s migration is not sorted", migration.ID)
+		}
+	}
+}
+
+func TestRunnerUp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	runner := &Runner{
+		DB: db,
+		Migrations: []*Migration{
+			{ID: "1-a.sql", Up: []string{"CREATE TABLE a (id INT);"}},
+			{ID: "2-b.sql", Up: []string{"CREATE TABLE b (id INT);"}},
+		},
+		LockTimeout: time.Minute,
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT GET_LOCK\\(\\?, \\?\\)").WithArgs("artworks_migrations", 60).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(1))
+	mock.ExpectQuery("SELECT id, applied_at FROM migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "applied_at"}).AddRow("1-a.sql", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO migrations").WithArgs("2-b.sql", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("DO RELEASE_LOCK\\(\\?\\)").WithArgs("artworks_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := runner.Up(context.Background())
+	if err != nil {
+		t.Errorf("Up returned a non expected error. Err: %s", err)
+		return
+	}
+
+	if strings.Join(applied, ",") != "2-b.sql" {
+		t.Errorf("The applied migrations don't match Got: %v Expected: [2-b.sql]", applied)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestRunnerLocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	runner := &Runner{
+		DB:          db,
+		Migrations:  []*Migration{{ID: "1-a.sql", Up: []string{"CREATE TABLE a (id INT);"}}},
+		LockTimeout: time.Second,
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT GET_LOCK\\(\\?, \\?\\)").WithArgs("artworks_migrations", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(0))
+
+	_, err = runner.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "artworks_migrations") {
+		t.Errorf("Up should fail while other runner holds the lock. Err: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
+
+func TestRunnerCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unable to open a stub database connection. Err %s", err)
+	}
+	defer db.Close()
+
+	runner := &Runner{
+		DB: db,
+		Migrations: []*Migration{
+			{ID: "1-a.sql", Up: []string{"CREATE TABLE a (id INT);"}},
+			{ID: "2-b.sql", Up: []string{"CREATE TABLE b (id INT);"}},
+		},
+	}
+
+	mock.ExpectQuery("SELECT id, applied_at FROM migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "applied_at"}).AddRow("1-a.sql", time.Now()))
+	mock.ExpectQuery("SELECT id, applied_at FROM migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "applied_at"}).
+			AddRow("1-a.sql", time.Now()).AddRow("2-b.sql", time.Now()))
+
+	if err := runner.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "2-b.sql") {
+		t.Errorf("Check should fail with a pending migration. Err: %v", err)
+	}
+
+	if err := runner.Check(context.Background()); err != nil {
+		t.Errorf("Check returned a non expected error. Err: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expections: %s", err)
+		return
+	}
+}
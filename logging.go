@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jcleira/artworks-api/config"
+)
+
+// jsonLogWriter writes every log line as a JSON object, so the logs could be
+// parsed by the cluster log collectors.
+type jsonLogWriter struct {
+	w io.Writer
+}
+
+func (jw *jsonLogWriter) Write(p []byte) (int, error) {
+	data, err := json.Marshal(struct {
+		Time    string `json:"time"`
+		Message string `json:"message"`
+	}{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Message: string(bytes.TrimRight(p, "\n")),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := jw.w.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// configureLog sets the standard logger output and format.
+//
+// settings: The logging settings.
+//
+// Returns an error if the log file can't be opened.
+func configureLog(settings config.Log) error {
+	var w io.Writer = os.Stderr
+
+	if settings.Output != "" {
+		f, err := os.OpenFile(settings.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+
+	if settings.Format == "json" {
+		log.SetFlags(0)
+		w = &jsonLogWriter{w: w}
+	}
+
+	log.SetOutput(w)
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jcleira/artworks-api/data/migrations"
+)
+
+// migrateUsage describes the migrate subcommand.
+const migrateUsage = "Usage: artworks-api [flags] migrate up|down|status"
+
+// runMigrate runs the migrate subcommand.
+//
+// up: Applies every pending migration.
+// down: Rolls back the last applied migration.
+// status: Lists the migrations and when they were applied.
+//
+// ctx: The command context.
+// runner: The migrations Runner.
+// args: The subcommand arguments, after migrate.
+// w: Where to write the status.
+//
+// Returns an error if any.
+func runMigrate(ctx context.Context, runner *migrations.Runner, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up(ctx)
+		for _, id := range applied {
+			log.Printf("Applied the %s migration", id)
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migrations", len(applied))
+
+	case "down":
+		id, err := runner.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			log.Printf("There are no migrations to roll back")
+			return nil
+		}
+		log.Printf("Rolled back the %s migration", id)
+
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%-50s %s\n", status.ID, appliedAt)
+		}
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
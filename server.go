@@ -1,82 +1,305 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
-
-	yaml "gopkg.in/yaml.v2"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
 	"github.com/jcleira/artworks-api/artworks"
+	"github.com/jcleira/artworks-api/config"
+	"github.com/jcleira/artworks-api/data/migrations"
 )
 
-// config is the configuration struct, it contains all the settings needed to
-// run the server.
+// configureRoutes will configure all the REST API routes, it returns a *mux.Router
+// with all the core api routes configured and instrumented. The health and
+// public routes are open, the public ones only serve the given public fields,
+// every other route requires the requests to be authenticated by the given
+// Authenticator.
+func configureRoutes(artworksClient *artworks.Client, authenticator *artworks.Authenticator, health *artworks.Health,
+	publicFields []string) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(artworks.MetricsMiddleware)
+
+	artworks.ConfigureHealthHandlers(r, health)
+	artworks.ConfigurePublicHandlers(r, artworksClient, publicFields)
+
+	api := r.NewRoute().Subrouter()
+	api.Use(authenticator.Middleware)
+
+	artworks.ConfigureHandlers(api, artworksClient)
+
+	return r
+}
+
+// configureAdminRoutes will configure the admin REST API routes, it returns a
+// *mux.Router with the metrics and the operations that can't be undone, it
+// should only be reachable by the administrators and the metrics scraper.
+// Every route but the metrics one requires the requests to be authenticated
+// by the given Authenticator.
+func configureAdminRoutes(artworksClient *artworks.Client, authenticator *artworks.Authenticator) *mux.Router {
+	r := mux.NewRouter()
+
+	artworks.ConfigureMetricsHandlers(r, artworksClient)
+
+	admin := r.NewRoute().Subrouter()
+	admin.Use(authenticator.Middleware)
+
+	artworks.ConfigureAdminHandlers(admin, artworksClient)
+
+	return r
+}
+
+// getAuthenticator builds the API Authenticator, JWT bearer tokens are only
+// accepted when a HS256 secret or a RS256 public key file is given.
 //
-// TODO Important!. This development struct should be removed, should we use
-// a config management tool?.
-type config struct {
-	Development struct {
-		Datasource string `yaml:"datasource"`
+// apiKeys: The API keys client.
+// settings: The JWT settings.
+//
+// Returns:
+// The Authenticator.
+// An error if the JWT keys can't be read.
+func getAuthenticator(apiKeys artworks.APIKeysController, settings config.Auth) (*artworks.Authenticator, error) {
+	authenticator := &artworks.Authenticator{
+		APIKeys:  apiKeys,
+		Issuer:   settings.JWTIssuer,
+		Audience: settings.JWTAudience,
 	}
-	Preproduction struct {
-		Datasource string `yaml:"datasource"`
+
+	if settings.JWTSecretFile != "" {
+		secret, err := ioutil.ReadFile(settings.JWTSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the JWT secret. Err: %s", err)
+		}
+		authenticator.HS256Secret = bytes.TrimSpace(secret)
+	}
+
+	if settings.JWTPublicKeyFile != "" {
+		data, err := ioutil.ReadFile(settings.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the JWT public key. Err: %s", err)
+		}
+
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse the JWT public key. Err: %s", err)
+		}
+		authenticator.RS256Key = key
+	}
+
+	return authenticator, nil
+}
+
+// newServer builds a http.Server with the configured timeouts, so slow or
+// stalled clients can't hold the connections forever.
+//
+// handler: The http.Handler to serve.
+// settings: The HTTP settings.
+//
+// Returns the http.Server.
+func newServer(handler http.Handler, settings config.HTTP) *http.Server {
+	return &http.Server{
+		Handler:        handler,
+		ReadTimeout:    settings.ReadTimeout,
+		WriteTimeout:   settings.WriteTimeout,
+		IdleTimeout:    settings.IdleTimeout,
+		MaxHeaderBytes: settings.MaxHeaderBytes,
+	}
+}
+
+// serve runs every server on its listener until any of them fails or a
+// stop signal is received, then it shuts all of them down, waiting up to
+// timeout for the in-flight requests to finish.
+//
+// servers: The http.Servers to run.
+// listeners: The servers listeners, by position.
+// stop: Receives the stop signals.
+// timeout: How long to wait for the in-flight requests.
+//
+// Returns an error if any server failed or it couldn't be drained in time.
+func serve(servers []*http.Server, listeners []net.Listener, stop <-chan os.Signal, timeout time.Duration) error {
+	errs := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(server *http.Server, listener net.Listener) {
+			if err := server.Serve(listener); err != http.ErrServerClosed {
+				errs <- fmt.Errorf("Unable to serve on %s. Err: %s", listener.Addr(), err)
+			}
+		}(server, listeners[i])
+	}
+
+	var err error
+	select {
+	case err = <-errs:
+	case sig := <-stop:
+		log.Printf("Received %s, draining the connections", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(ctx); shutdownErr != nil && err == nil {
+			err = fmt.Errorf("Unable to drain the connections. Err: %s", shutdownErr)
+		}
 	}
+
+	return err
 }
 
-// getConfiguration reads all the necesary configurations for the server to run.
+// run initializes and runs the http server until it's stopped, or the
+// given command.
+//
+// args: The command line arguments, without the program name.
 //
-// Returns a configuration struct.
-func getConfiguration() *config {
-	data, err := ioutil.ReadFile("dbconfig.yml")
+// Returns an error if the server can't be started or it failed.
+func run(args []string) error {
+	cfg, args, err := config.Load(os.Args[0], args, os.Getenv)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	if err := configureLog(cfg.Log); err != nil {
+		return err
+	}
+
+	fields := artworks.DefaultPublicFields
+	if len(cfg.Public.Fields) > 0 {
+		if fields, err = artworks.ParsePublicFields(strings.Join(cfg.Public.Fields, ",")); err != nil {
+			return err
+		}
 	}
-	var config config
 
-	err = yaml.Unmarshal([]byte(data), &config)
+	db, err := sql.Open("mysql", cfg.Database.DSN)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer db.Close()
 
-	return &config
-}
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
 
-// configureRoutes will configure all the REST API routes, it returns a *mux.Router
-// with all the core api routes configured.
-func configureRoutes(db *sql.DB) *mux.Router {
-	r := mux.NewRouter()
+	loaded, err := migrations.Load()
+	if err != nil {
+		return err
+	}
 
-	artworks.ConfigureHandlers(r, db)
+	runner := &migrations.Runner{
+		DB:          db,
+		Migrations:  loaded,
+		LockTimeout: cfg.Migrations.LockTimeout,
+	}
 
-	return r
-}
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			return fmt.Errorf("Unknown %s command. %s", args[0], migrateUsage)
+		}
 
-// main would initialize and run the http server.
-func main() {
-	environment := flag.String("environment", "development", "Running environment")
-	flag.Parse()
+		return runMigrate(context.Background(), runner, args[1:], os.Stdout)
+	}
 
-	config := getConfiguration()
+	if cfg.Migrations.OnStart {
+		applied, err := runner.Up(context.Background())
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migrations on startup", len(applied))
+	}
 
-	datasource := config.Development.Datasource
+	storage := &artworks.LocalStorage{Dir: cfg.Images.Dir}
 
-	switch *environment {
-	case "preproduction":
-		datasource = config.Preproduction.Datasource
-		break
+	derivatives := artworks.NewDerivativeWorker(storage, 100)
+	derivatives.MaxImagePixels = cfg.Images.MaxPixels
+	derivatives.Start(cfg.Images.DerivativeWorkers)
+	// The servers are shut down before, so no request could enqueue images.
+	defer derivatives.Close()
 
+	artworksClient := &artworks.Client{
+		DB:             db,
+		Storage:        storage,
+		Derivatives:    derivatives,
+		QueryTimeout:   cfg.Database.QueryTimeout,
+		MaxImagePixels: cfg.Images.MaxPixels,
+	}
+	if err := artworksClient.CheckSchema(context.Background()); err != nil {
+		return err
 	}
 
-	db, err := sql.Open("mysql", datasource)
+	authenticator, err := getAuthenticator(artworksClient, cfg.Auth)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer db.Close()
 
-	http.ListenAndServe(":3000", configureRoutes(db))
+	cors := &artworks.CORS{
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedMethods: cfg.CORS.AllowedMethods,
+		AllowedHeaders: cfg.CORS.AllowedHeaders,
+		ExposedHeaders: cfg.CORS.ExposedHeaders,
+		MaxAge:         cfg.CORS.MaxAge,
+	}
+
+	health := &artworks.Health{
+		Checks: map[string]artworks.HealthCheck{
+			"database":   artworksClient.Ping,
+			"migrations": runner.Check,
+		},
+		Timeout: cfg.HTTP.ReadinessTimeout,
+	}
+
+	servers := []*http.Server{
+		newServer(cors.Handler(configureRoutes(artworksClient, authenticator, health, fields)), cfg.HTTP),
+		newServer(configureAdminRoutes(artworksClient, authenticator), cfg.HTTP),
+	}
+
+	var listeners []net.Listener
+	for _, address := range []string{cfg.HTTP.Address, cfg.HTTP.AdminAddress} {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return fmt.Errorf("Unable to listen on %s. Err: %s", address, err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(stop)
+
+	log.Printf("Starting the %s environment API on %s", cfg.Environment, cfg.HTTP.Address)
+
+	if err := serve(servers, listeners, stop, cfg.HTTP.ShutdownTimeout); err != nil {
+		return err
+	}
+
+	log.Printf("The API was stopped")
+	return nil
+}
+
+// main runs the http server, it exits with 0 once stopped (or when the usage
+// was requested) and with 1 on any failure.
+func main() {
+	err := run(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+
+	if err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/jcleira/artworks-api/artworks"
+	"github.com/jcleira/artworks-api/config"
+)
+
+func TestServe(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Errorf("Unable to listen. Err: %s", err)
+		return
+	}
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		server := newServer(handler, config.Default().HTTP)
+		served <- serve([]*http.Server{server}, []net.Listener{listener}, stop, time.Second)
+	}()
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+
+	<-started
+	stop <- syscall.SIGTERM
+
+	if statusCode := <-responses; statusCode != http.StatusNoContent {
+		t.Errorf("The in-flight request was not drained Got: %d Expected: %d", statusCode, http.StatusNoContent)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("serve returned a non expected error. Err: %s", err)
+	}
+}
+
+func TestServeFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Errorf("Unable to listen. Err: %s", err)
+		return
+	}
+	listener.Close()
+
+	server := newServer(http.NotFoundHandler(), config.Default().HTTP)
+	err = serve([]*http.Server{server}, []net.Listener{listener}, make(chan os.Signal), time.Second)
+	if err == nil {
+		t.Errorf("serve should fail on a closed listener")
+	}
+}
+
+func TestConfigureAdminRoutes(t *testing.T) {
+	secret := []byte("s3cr3t")
+	authenticator := &artworks.Authenticator{HS256Secret: secret}
+
+	token := func(role string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "jcleira", "role": role, "exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		if err != nil {
+			t.Errorf("Unable to sign a JWT token. Err: %s", err)
+		}
+		return "Bearer " + signed
+	}
+
+	r := configureAdminRoutes(&artworks.Client{}, authenticator)
+
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		authorization string
+		statusCode    int
+	}{
+		{name: "purge without credentials", method: "DELETE", url: "/artworks/trash/1", statusCode: http.StatusUnauthorized},
+		{name: "purge as curator", method: "DELETE", url: "/artworks/trash/1", authorization: token("curator"),
+			statusCode: http.StatusForbidden},
+		{name: "create API key without credentials", method: "POST", url: "/api-keys", statusCode: http.StatusUnauthorized},
+		{name: "revoke API key with an invalid token", method: "DELETE", url: "/api-keys/1", authorization: "Bearer f0rg3d",
+			statusCode: http.StatusUnauthorized},
+		{name: "revoke API key as editor", method: "DELETE", url: "/api-keys/1", authorization: token("editor"),
+			statusCode: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.statusCode {
+			t.Errorf("The response Status Code don't match for %s Got: %d Expected: %d", test.name, w.Code, test.statusCode)
+		}
+	}
+}
+
+func TestConfigureRoutesMetrics(t *testing.T) {
+	r := configureRoutes(&artworks.Client{}, &artworks.Authenticator{}, &artworks.Health{}, artworks.DefaultPublicFields)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("The metrics should only be served on the admin listener Got: %d Expected: %d", w.Code, http.StatusNotFound)
+	}
+}